@@ -3,19 +3,39 @@
 
 package main
 
+import "time"
+
 // Cart Struct
 type Cart struct {
-	ID       string    `json:"id" yaml:"id"`
-	Username string    `json:"username" yaml:"username"`
-	Items    CartItems `json:"items" yaml:"items"`
+	ID                string             `json:"id" yaml:"id"`
+	Username          string             `json:"username" yaml:"username" dynamodbav:"username,omitempty"` // omitted when empty so it can key an index
+	Items             CartItems          `json:"items" yaml:"items"`
+	Subtotal          float32            `json:"subtotal" yaml:"subtotal"`
+	ItemCount         int                `json:"item_count" yaml:"item_count"`
+	Currency          string             `json:"currency" yaml:"currency"`
+	PromotionCodes    []string           `json:"promotion_codes" yaml:"promotion_codes"`
+	Promotions        []AppliedPromotion `json:"promotions" yaml:"promotions"`
+	Discount          float32            `json:"discount" yaml:"discount"`
+	Tax               float32            `json:"tax" yaml:"tax"`
+	TaxLines          []TaxLine          `json:"tax_lines" yaml:"tax_lines"`
+	Total             float32            `json:"total" yaml:"total"`
+	ShippingAddress   *Address           `json:"shipping_address,omitempty" yaml:"shipping_address,omitempty"` // address tax is estimated for
+	FreeShipping      bool               `json:"free_shipping" yaml:"free_shipping"`
+	CreatedAt         time.Time          `json:"created_at" yaml:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at" yaml:"updated_at"`
+	AbandonedNotified bool               `json:"-" yaml:"-" dynamodbav:"abandoned_notified"` // internal to the sweeper, so not part of the versioned document clients see
+	Version           int                `json:"version" yaml:"version"`
 }
 
 // CartItem Struct
 type CartItem struct {
-	ProductID   string  `json:"product_id" yaml:"product_id"`
-	ProductName string  `json:"product_name" yaml:"product_name"`
-	Quantity    int     `json:"quantity" yaml:"quantity"`
-	Price       float32 `json:"price" yaml:"price"`
+	ProductID      string  `json:"product_id" yaml:"product_id"`
+	ProductName    string  `json:"product_name" yaml:"product_name"`
+	Quantity       int     `json:"quantity" yaml:"quantity"`
+	Price          float32 `json:"price" yaml:"price"`
+	PriceWhenAdded float32 `json:"price_when_added" yaml:"price_when_added"`
+	PriceChanged   bool    `json:"price_changed" yaml:"price_changed"`
+	Discount       float32 `json:"discount" yaml:"discount"`
 }
 
 // CartItems Array
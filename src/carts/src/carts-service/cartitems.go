@@ -0,0 +1,93 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+)
+
+// ErrInvalidQuantity is returned when an item quantity is not positive
+var ErrInvalidQuantity = errors.New("Item quantity must be greater than zero")
+
+// ErrMissingProductID is returned when an item does not reference a product
+var ErrMissingProductID = errors.New("Item product_id is required")
+
+// ErrCartItemNotFound is returned when a cart has no item for a product
+var ErrCartItemNotFound = errors.New("Cart item not found")
+
+// ErrMergeSameCart is returned when a cart is merged into itself
+var ErrMergeSameCart = errors.New("A cart cannot be merged into itself")
+
+// CartItemQuantity Struct - request body for changing an item's quantity
+type CartItemQuantity struct {
+	Quantity int `json:"quantity" yaml:"quantity"`
+}
+
+// Merged returns the items with duplicate products combined into a single
+// line whose quantity is the sum of the duplicates. The first occurrence of a
+// product keeps its position and other details.
+func (items CartItems) Merged() CartItems {
+	if items == nil {
+		return nil
+	}
+
+	merged := make(CartItems, 0, len(items))
+	positions := make(map[string]int, len(items))
+
+	for _, item := range items {
+		if idx, ok := positions[item.ProductID]; ok {
+			merged[idx].Quantity += item.Quantity
+			continue
+		}
+		positions[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+
+	return merged
+}
+
+// AddItem adds item to the cart, adding to the quantity of an existing line
+// for the same product.
+func (c *Cart) AddItem(item CartItem) error {
+	if len(item.ProductID) == 0 {
+		return ErrMissingProductID
+	}
+	if item.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	// Recorded from the catalog when the cart is priced
+	item.PriceWhenAdded = 0
+
+	c.Items = append(c.Items, item).Merged()
+	return nil
+}
+
+// SetItemQuantity changes the quantity of the line for productID
+func (c *Cart) SetItemQuantity(productID string, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items[i].Quantity = quantity
+			return nil
+		}
+	}
+
+	return ErrCartItemNotFound
+}
+
+// RemoveItem removes the line for productID from the cart
+func (c *Cart) RemoveItem(productID string) error {
+	for i := range c.Items {
+		if c.Items[i].ProductID == productID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrCartItemNotFound
+}
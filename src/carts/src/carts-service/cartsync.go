@@ -0,0 +1,166 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// How often an idle cart event stream sends a heartbeat so proxies and
+// clients don't time out the connection
+var cartSyncHeartbeat = time.Duration(getEnvInt("CART_SYNC_HEARTBEAT_SECONDS", 15)) * time.Second
+
+// CartChange Struct - a change to a cart delivered to subscribers
+type CartChange struct {
+	Cart    Cart
+	Deleted bool
+}
+
+// CartBroker fans cart changes out to the clients watching each cart. The
+// in-process broker only reaches clients connected to this instance; a shared
+// broker can implement the same interface when carts are served by several
+// instances. Implementations must be safe for concurrent use.
+type CartBroker interface {
+	// Subscribe returns a channel of changes to the cart and a function that
+	// ends the subscription. The channel is closed when the subscription ends
+	// or the broker is closed.
+	Subscribe(cartID string) (<-chan CartChange, func())
+	// Publish delivers a change to the cart's subscribers without blocking
+	Publish(change CartChange)
+	// Close ends all subscriptions
+	Close()
+}
+
+var cartBroker CartBroker = NewMemoryCartBroker()
+
+// MemoryCartBroker delivers changes to subscribers in this process. Slow
+// subscribers only receive the latest change, since each change carries the
+// whole cart.
+type MemoryCartBroker struct {
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[string]map[chan CartChange]bool
+}
+
+// NewMemoryCartBroker Function
+func NewMemoryCartBroker() *MemoryCartBroker {
+	return &MemoryCartBroker{subscribers: map[string]map[chan CartChange]bool{}}
+}
+
+// Subscribe Function
+func (b *MemoryCartBroker) Subscribe(cartID string) (<-chan CartChange, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan CartChange, 1)
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if b.subscribers[cartID] == nil {
+		b.subscribers[cartID] = map[chan CartChange]bool{}
+	}
+	b.subscribers[cartID][ch] = true
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { b.unsubscribe(cartID, ch) })
+	}
+}
+
+// unsubscribe removes and closes a subscriber channel unless Close already has
+func (b *MemoryCartBroker) unsubscribe(cartID string, ch chan CartChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subscribers[cartID]
+	if !subs[ch] {
+		return
+	}
+	delete(subs, ch)
+	if len(subs) == 0 {
+		delete(b.subscribers, cartID)
+	}
+	close(ch)
+}
+
+// Publish Function
+func (b *MemoryCartBroker) Publish(change CartChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[change.Cart.ID] {
+		select {
+		case ch <- change:
+			continue
+		default:
+		}
+
+		// Replace the change the subscriber hasn't read yet with this one
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// Close Function
+func (b *MemoryCartBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for cartID, subs := range b.subscribers {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(b.subscribers, cartID)
+	}
+}
+
+// BroadcastingCartStore publishes every successful update and delete made
+// through the wrapped store, so subscribers see changes however they are made.
+// MarkAbandoned isn't published since it doesn't change what clients see.
+type BroadcastingCartStore struct {
+	CartStore
+	broker CartBroker
+}
+
+// NewBroadcastingCartStore Function
+func NewBroadcastingCartStore(store CartStore, broker CartBroker) *BroadcastingCartStore {
+	return &BroadcastingCartStore{CartStore: store, broker: broker}
+}
+
+// Update Function
+func (s *BroadcastingCartStore) Update(cart Cart) (Cart, error) {
+	updated, err := s.CartStore.Update(cart)
+	if err == nil {
+		s.broker.Publish(CartChange{Cart: copyCart(updated)})
+	}
+	return updated, err
+}
+
+// Delete Function
+func (s *BroadcastingCartStore) Delete(id string) error {
+	err := s.CartStore.Delete(id)
+	if err == nil {
+		s.broker.Publish(CartChange{Cart: Cart{ID: id}, Deleted: true})
+	}
+	return err
+}
+
+// DeleteVersion Function
+func (s *BroadcastingCartStore) DeleteVersion(id string, version int) error {
+	err := s.CartStore.DeleteVersion(id, version)
+	if err == nil {
+		s.broker.Publish(CartChange{Cart: Cart{ID: id}, Deleted: true})
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"shared/catalog"
+	"shared/services"
+)
+
+// Products service location passed via environment (local development).
+// When not set, the products service is discovered through Cloud Map.
+var productsService = services.NewEndpoint("products", os.Getenv("PRODUCT_SERVICE_HOST"), os.Getenv("PRODUCT_SERVICE_PORT"), sess)
+
+// CatalogProduct Struct - the fields of a products service Product
+type CatalogProduct = catalog.Product
+
+// ProductCatalog looks up products in the products service
+type ProductCatalog interface {
+	// FindProducts returns the products that exist for ids keyed by product ID
+	FindProducts(ids []string) (map[string]CatalogProduct, error)
+	// Reserve sets the quantity of a product held by a reservation for ttl.
+	// A quantity of zero releases the reservation.
+	Reserve(productID string, reservationID string, quantity int, ttl time.Duration) error
+}
+
+var productCatalog ProductCatalog = NewHTTPProductCatalog()
+
+// HTTPProductCatalog calls the products service REST API
+type HTTPProductCatalog struct {
+	client   *http.Client
+	endpoint *services.Endpoint
+}
+
+// NewHTTPProductCatalog Function
+func NewHTTPProductCatalog() *HTTPProductCatalog {
+	return &HTTPProductCatalog{client: &http.Client{Timeout: 5 * time.Second}, endpoint: productsService}
+}
+
+// FindProducts Function
+func (c *HTTPProductCatalog) FindProducts(ids []string) (map[string]CatalogProduct, error) {
+	return catalog.FindProducts(c.client, c.endpoint, ids)
+}
+
+// reservationRequest Struct - request body of the products service reservation API
+type reservationRequest struct {
+	Quantity   int `json:"quantity"`
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+// Reserve Function
+func (c *HTTPProductCatalog) Reserve(productID string, reservationID string, quantity int, ttl time.Duration) error {
+	path := "/products/id/" + url.PathEscape(productID) + "/reservations/" + url.PathEscape(reservationID)
+	request := reservationRequest{Quantity: quantity, TTLSeconds: int(ttl / time.Second)}
+
+	body, status, err := c.endpoint.Do(c.client, "PUT", path, request, nil)
+	if err != nil {
+		return err
+	}
+	return reservationError(productID, status, body)
+}
+
+// reservationError maps a products service reservation response to an error
+func reservationError(productID string, status int, body []byte) error {
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return &ProductNotFoundError{ProductID: productID}
+	case http.StatusConflict:
+		var shortage StockShortage
+		if err := json.Unmarshal(body, &shortage); err == nil && len(shortage.ProductID) > 0 {
+			return &OutOfStockError{Items: []StockShortage{shortage}}
+		}
+	}
+	return fmt.Errorf("products service returned status %d updating reservation for %s", status, productID)
+}
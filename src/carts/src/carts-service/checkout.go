@@ -0,0 +1,287 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/address"
+	"shared/validation"
+)
+
+// Delivery types understood by the orders service and web UI
+const (
+	DeliveryTypeDelivery   = "DELIVERY"
+	DeliveryTypeCollection = "COLLECTION"
+)
+
+// ErrCartEmpty is returned when checking out a cart without items
+var ErrCartEmpty = errors.New("Cart has no items")
+
+// ValidationError is returned when a checkout request has one or more invalid
+// fields
+type ValidationError = validation.Error
+
+// ErrOrderServiceUnavailable is returned when the order could not be created
+var ErrOrderServiceUnavailable = errors.New("Unable to create order")
+
+// ErrCartChangedDuringCheckout is returned when the cart was modified after
+// checkout priced and reserved it
+var ErrCartChangedDuringCheckout = errors.New("Cart was modified during checkout; check out again")
+
+// CheckoutRequest Struct - details needed to turn a cart into an order
+type CheckoutRequest struct {
+	BillingAddress  Address       `json:"billing_address"`
+	ShippingAddress Address       `json:"shipping_address"`
+	CollectionPhone string        `json:"collection_phone"`
+	DeliveryType    string        `json:"delivery_type"`
+	Channel         string        `json:"channel"`
+	ChannelDetail   ChannelDetail `json:"channel_detail"`
+	// ShippingOptionID is the id of an option quoted by the orders service's
+	// POST /shipping/quotes; the orders service prices it
+	ShippingOptionID string `json:"shipping_option_id"`
+}
+
+// CheckoutResponse Struct
+type CheckoutResponse struct {
+	OrderID string `json:"order_id"`
+	Order   Order  `json:"order"`
+}
+
+// StockShortage Struct - an item that can't be fulfilled from current stock
+type StockShortage struct {
+	ProductID string `json:"product_id"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// OutOfStockError is returned when one or more items exceed available stock
+type OutOfStockError struct {
+	Items []StockShortage `json:"items"`
+}
+
+func (e *OutOfStockError) Error() string {
+	return "Insufficient stock for one or more items"
+}
+
+// Validate checks the request has the details required for its delivery type
+// and normalizes its addresses, so the cart is taxed at the same address the
+// orders service will tax the order at. Delivery orders need billing and
+// shipping addresses that are valid in their country; collection orders need
+// a billing address and a phone number.
+func (req *CheckoutRequest) Validate() error {
+	verr := &ValidationError{}
+
+	req.DeliveryType = strings.ToUpper(strings.TrimSpace(req.DeliveryType))
+	if len(req.DeliveryType) == 0 {
+		req.DeliveryType = DeliveryTypeDelivery
+	}
+
+	req.BillingAddress = validateAddress(verr, "billing_address", req.BillingAddress)
+
+	switch req.DeliveryType {
+	case DeliveryTypeDelivery:
+		req.ShippingAddress = validateAddress(verr, "shipping_address", req.ShippingAddress)
+	case DeliveryTypeCollection:
+		if len(strings.TrimSpace(req.CollectionPhone)) == 0 {
+			verr.Add("collection_phone", "is required for collection orders")
+		}
+	default:
+		verr.Add("delivery_type", "must be DELIVERY or COLLECTION")
+	}
+
+	return verr.OrNil()
+}
+
+// validateAddress normalizes the address and checks it has the fields needed
+// to bill or ship to it in its country
+func validateAddress(verr *ValidationError, field string, a Address) Address {
+	a = address.Normalize(a)
+	verr.AddAll(field, address.Validate(a))
+	return a
+}
+
+// CheckoutCart turns a cart into an order. The cart's stock reservations are
+// refreshed before the order is created, and the orders service commits them
+// when it creates the order, which decrements inventory.
+//
+// The cart is emptied before the order is created. It is only emptied if it
+// is still the version that was priced and reserved; a cart changed during
+// checkout fails with ErrCartChangedDuringCheckout. If the checkout then
+// fails, the items and promotion codes are put back in the cart.
+//
+// The order is created with an Idempotency-Key of the cart ID and version, so
+// a retried request can't create a second order for the same cart contents.
+// Stock shortages and errors the orders service reports for the order itself
+// are returned to the client; other failures return ErrOrderServiceUnavailable.
+func CheckoutCart(cartID string, req CheckoutRequest) (Order, error) {
+	if err := req.Validate(); err != nil {
+		return Order{}, err
+	}
+
+	cart, err := cartStore.FindByID(cartID)
+	if err != nil {
+		return Order{}, err
+	}
+	if len(cart.Items) == 0 {
+		return Order{}, ErrCartEmpty
+	}
+	stored := cart
+
+	// Tax the cart at the normalized address the order will be taxed at, with
+	// the same rules, so their totals agree
+	taxAddress := req.ShippingAddress
+	if req.DeliveryType == DeliveryTypeCollection {
+		taxAddress = req.BillingAddress
+	}
+	cart.ShippingAddress = &taxAddress
+	if err := PriceCart(&cart, productCatalog); err != nil {
+		return Order{}, err
+	}
+
+	if err := reserveStock(cartID, cart.Items); err != nil {
+		return Order{}, err
+	}
+
+	if err := emptyCart(stored); err != nil {
+		return Order{}, err
+	}
+
+	order, err := orderService.CreateOrder(newOrderFromCart(cart, req), cart.ID+"-"+strconv.Itoa(stored.Version))
+	if err != nil {
+		log.Println("CheckoutCart unable to create order: ", err)
+		restoreCart(cartID, cart.Items, cart.PromotionCodes)
+		switch err.(type) {
+		case *OutOfStockError, *ValidationError, *OrderRejectedError:
+			return Order{}, err
+		}
+		return Order{}, ErrOrderServiceUnavailable
+	}
+
+	return order, nil
+}
+
+// emptyCart removes the items and promotion codes from the cart being checked
+// out, failing with ErrCartChangedDuringCheckout if it has changed since it
+// was read. The store is written directly so the cart's reservations are kept for
+// the orders service to commit.
+func emptyCart(cart Cart) error {
+	cart.Items = CartItems{}
+	cart.PromotionCodes = nil
+	cart.UpdatedAt = time.Now().UTC()
+	if err := PriceCart(&cart, productCatalog); err != nil {
+		return err
+	}
+
+	if _, err := cartStore.Update(cart); err != nil {
+		if err == ErrCartVersionConflict {
+			return ErrCartChangedDuringCheckout
+		}
+		if err != ErrCartNotFound {
+			log.Println("emptyCart error: ", cart.ID, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// restoreCart puts the items and promotion codes of a failed checkout back in
+// the cart, alongside anything added while the checkout ran, and reserves
+// stock for the combined quantities. Failures are only logged since the
+// checkout has already failed.
+func restoreCart(cartID string, items CartItems, codes []string) {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		cart, err := cartStore.FindByID(cartID)
+		if err != nil {
+			log.Println("restoreCart error: ", cartID, err)
+			return
+		}
+
+		cart.Items = append(append(CartItems{}, cart.Items...), items...).Merged()
+		if len(cart.PromotionCodes) == 0 {
+			cart.PromotionCodes = codes
+		}
+		cart.UpdatedAt = time.Now().UTC()
+		if err := PriceCart(&cart, productCatalog); err != nil {
+			log.Println("restoreCart error: ", cartID, err)
+			return
+		}
+
+		_, err = cartStore.Update(cart)
+		if err == ErrCartVersionConflict {
+			continue
+		}
+		if err != nil {
+			log.Println("restoreCart error: ", cartID, err)
+			return
+		}
+
+		if err := reserveStock(cartID, cart.Items); err != nil {
+			log.Println("restoreCart unable to reserve stock: ", cartID, err)
+		}
+		return
+	}
+	log.Println("restoreCart gave up after repeated version conflicts: ", cartID)
+}
+
+// reserveStock makes sure stock is reserved for every item, renewing
+// reservations that have expired. All shortages are reported together.
+func reserveStock(cartID string, items CartItems) error {
+	var shortages []StockShortage
+	for productID, quantity := range itemQuantities(items) {
+		err := reserve(productID, cartID, quantity)
+		if stockErr, ok := err.(*OutOfStockError); ok {
+			shortages = append(shortages, stockErr.Items...)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(shortages) > 0 {
+		return &OutOfStockError{Items: shortages}
+	}
+	return nil
+}
+
+// newOrderFromCart builds the order for a priced cart
+func newOrderFromCart(cart Cart, req CheckoutRequest) Order {
+	order := Order{
+		Username:        cart.Username,
+		CartID:          cart.ID,
+		Items:           make(OrderItems, 0, len(cart.Items)),
+		Subtotal:        cart.Subtotal,
+		Discount:        cart.Discount,
+		Tax:             cart.Tax,
+		Total:           cart.Total,
+		PromotionCodes:  cart.PromotionCodes,
+		FreeShipping:    cart.FreeShipping,
+		BillingAddress:  req.BillingAddress,
+		ShippingAddress: req.ShippingAddress,
+		CollectionPhone: req.CollectionPhone,
+		DeliveryType:    req.DeliveryType,
+		Channel:         req.Channel,
+		ChannelDetail:   req.ChannelDetail,
+	}
+	if len(req.ShippingOptionID) > 0 {
+		order.Shipping = &ShippingSelection{OptionID: req.ShippingOptionID}
+	}
+
+	for _, item := range cart.Items {
+		order.Items = append(order.Items, OrderItem{
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			Price:       item.Price,
+			Discount:    item.Discount,
+		})
+	}
+
+	return order
+}
@@ -0,0 +1,204 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	guuuid "github.com/google/uuid"
+)
+
+// DynamoListStore persists lists in a DynamoDB table keyed by "id" with
+// "username-index" and "share-token-index" global secondary indexes
+type DynamoListStore struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewDynamoListStore Function
+func NewDynamoListStore(client *dynamodb.DynamoDB, tableName string) *DynamoListStore {
+	return &DynamoListStore{client: client, tableName: tableName}
+}
+
+// FindByID Function
+func (s *DynamoListStore) FindByID(id string) (List, error) {
+	var list List
+
+	result, err := s.client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+
+	if err != nil {
+		log.Println("get item error " + string(err.Error()))
+		return list, err
+	}
+
+	if result.Item == nil {
+		return list, ErrListNotFound
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &list)
+	return list, err
+}
+
+// FindByUsername Function
+func (s *DynamoListStore) FindByUsername(username string) ([]List, error) {
+	return s.query("username-index", "username", username)
+}
+
+// FindByShareToken Function
+func (s *DynamoListStore) FindByShareToken(token string) (List, error) {
+	lists, err := s.query("share-token-index", "share_token", token)
+	if err != nil {
+		return List{}, err
+	}
+	if len(lists) == 0 {
+		return List{}, ErrListNotFound
+	}
+	return lists[0], nil
+}
+
+// Create Function
+func (s *DynamoListStore) Create(list List) (List, error) {
+	list.ID = strings.ToLower(guuuid.New().String())
+	list.Version = 1
+
+	if err := s.put(list, expression.AttributeNotExists(expression.Name("id"))); err != nil {
+		return List{}, err
+	}
+
+	return list, nil
+}
+
+// Update Function
+func (s *DynamoListStore) Update(list List) (List, error) {
+	expected := list.Version
+	list.Version++
+
+	err := s.put(list, expression.Name("version").Equal(expression.Value(expected)))
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// Condition fails both for a stale version and a missing list
+		if _, err := s.FindByID(list.ID); err != nil {
+			return List{}, err
+		}
+		return List{}, ErrListVersionConflict
+	}
+	if err != nil {
+		return List{}, err
+	}
+
+	return list, nil
+}
+
+// Delete Function
+func (s *DynamoListStore) Delete(id string) error {
+	expr, err := expression.NewBuilder().WithCondition(expression.AttributeExists(expression.Name("id"))).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		ConditionExpression:      expr.Condition(),
+		ExpressionAttributeNames: expr.Names(),
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrListNotFound
+	}
+	if err != nil {
+		log.Println("Got error calling DeleteItem:")
+		log.Println(err.Error())
+	}
+
+	return err
+}
+
+// query returns the lists whose attribute equals value using indexName
+func (s *DynamoListStore) query(indexName string, attribute string, value string) ([]List, error) {
+	values := []List{}
+
+	keycond := expression.Key(attribute).Equal(expression.Value(value))
+	expr, err := expression.NewBuilder().WithKeyCondition(keycond).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String(s.tableName),
+		IndexName:                 aws.String(indexName),
+	}
+
+	var unmarshalErr error
+	err = s.client.QueryPages(params, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var lists []List
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &lists); unmarshalErr != nil {
+			return false
+		}
+		values = append(values, lists...)
+		return true
+	})
+
+	if err != nil {
+		log.Println("Got error QUERY expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return values, unmarshalErr
+}
+
+func (s *DynamoListStore) put(list List, condition expression.ConditionBuilder) error {
+	av, err := dynamodbattribute.MarshalMap(list)
+	if err != nil {
+		log.Println("Got error calling dynamodbattribute MarshalMap:")
+		log.Println(err.Error())
+		return err
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:                      av,
+		TableName:                 aws.String(s.tableName),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = s.client.PutItem(input)
+	if err != nil {
+		log.Println("Got error calling PutItem:")
+		log.Println(err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,277 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	guuuid "github.com/google/uuid"
+)
+
+// DynamoCartStore persists carts in a DynamoDB table keyed by "id"
+type DynamoCartStore struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewDynamoCartStore Function
+func NewDynamoCartStore(client *dynamodb.DynamoDB, tableName string) *DynamoCartStore {
+	return &DynamoCartStore{client: client, tableName: tableName}
+}
+
+// FindAll Function
+func (s *DynamoCartStore) FindAll() ([]Cart, error) {
+	values := []Cart{}
+
+	params := &dynamodb.ScanInput{
+		TableName: aws.String(s.tableName),
+	}
+
+	var unmarshalErr error
+	err := s.client.ScanPages(params, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var carts []Cart
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &carts); unmarshalErr != nil {
+			return false
+		}
+		values = append(values, carts...)
+		return true
+	})
+
+	if err != nil {
+		log.Println("Got error scanning carts:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return values, unmarshalErr
+}
+
+// FindByID Function
+func (s *DynamoCartStore) FindByID(id string) (Cart, error) {
+	var cart Cart
+
+	result, err := s.client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+
+	if err != nil {
+		log.Println("get item error " + string(err.Error()))
+		return cart, err
+	}
+
+	if result.Item == nil {
+		return cart, ErrCartNotFound
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &cart)
+	return cart, err
+}
+
+// FindByUsername Function
+func (s *DynamoCartStore) FindByUsername(username string) ([]Cart, error) {
+	values := []Cart{}
+
+	keycond := expression.Key("username").Equal(expression.Value(username))
+	expr, err := expression.NewBuilder().WithKeyCondition(keycond).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String(s.tableName),
+		IndexName:                 aws.String("username-index"),
+	}
+
+	var unmarshalErr error
+	err = s.client.QueryPages(params, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var carts []Cart
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &carts); unmarshalErr != nil {
+			return false
+		}
+		values = append(values, carts...)
+		return true
+	})
+
+	if err != nil {
+		log.Println("Got error QUERY expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return values, unmarshalErr
+}
+
+// Create Function
+func (s *DynamoCartStore) Create(cart Cart) (Cart, error) {
+	cart.ID = strings.ToLower(guuuid.New().String())
+	cart.Version = 1
+
+	if err := s.put(cart, expression.AttributeNotExists(expression.Name("id"))); err != nil {
+		return Cart{}, err
+	}
+
+	return cart, nil
+}
+
+// Update Function
+func (s *DynamoCartStore) Update(cart Cart) (Cart, error) {
+	expected := cart.Version
+	cart.Version++
+
+	err := s.put(cart, expression.Name("version").Equal(expression.Value(expected)))
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// Condition fails both for a stale version and a missing cart
+		if _, err := s.FindByID(cart.ID); err != nil {
+			return Cart{}, err
+		}
+		return Cart{}, ErrCartVersionConflict
+	}
+	if err != nil {
+		return Cart{}, err
+	}
+
+	return cart, nil
+}
+
+// Delete Function
+func (s *DynamoCartStore) Delete(id string) error {
+	err := s.delete(id, expression.AttributeExists(expression.Name("id")))
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrCartNotFound
+	}
+	return err
+}
+
+// DeleteVersion Function
+func (s *DynamoCartStore) DeleteVersion(id string, version int) error {
+	err := s.delete(id, expression.Name("version").Equal(expression.Value(version)))
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// Condition fails both for a stale version and a missing cart
+		if _, err := s.FindByID(id); err != nil {
+			return err
+		}
+		return ErrCartVersionConflict
+	}
+	return err
+}
+
+// MarkAbandoned Function
+func (s *DynamoCartStore) MarkAbandoned(id string, version int) error {
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("abandoned_notified"), expression.Value(true))).
+		WithCondition(expression.Name("version").Equal(expression.Value(version))).
+		Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	_, err = s.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// Condition fails both for a stale version and a missing cart
+		if _, err := s.FindByID(id); err != nil {
+			return err
+		}
+		return ErrCartVersionConflict
+	}
+	if err != nil {
+		log.Println("Got error calling UpdateItem:")
+		log.Println(err.Error())
+	}
+	return err
+}
+
+func (s *DynamoCartStore) delete(id string, condition expression.ConditionBuilder) error {
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	_, err = s.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+			log.Println("Got error calling DeleteItem:")
+			log.Println(err.Error())
+		}
+	}
+
+	return err
+}
+
+func (s *DynamoCartStore) put(cart Cart, condition expression.ConditionBuilder) error {
+	av, err := dynamodbattribute.MarshalMap(cart)
+	if err != nil {
+		log.Println("Got error calling dynamodbattribute MarshalMap:")
+		log.Println(err.Error())
+		return err
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:                      av,
+		TableName:                 aws.String(s.tableName),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = s.client.PutItem(input)
+	if err != nil {
+		log.Println("Got error calling PutItem:")
+		log.Println(err.Error())
+	}
+
+	return err
+}
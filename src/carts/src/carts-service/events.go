@@ -0,0 +1,128 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cart event types
+const (
+	EventTypeCartAbandoned = "CartAbandoned"
+)
+
+// CartEvent Struct - published for downstream consumers such as marketing flows
+type CartEvent struct {
+	EventType    string    `json:"event_type"`
+	Timestamp    time.Time `json:"timestamp"`
+	CartID       string    `json:"cart_id"`
+	Username     string    `json:"username"`
+	Items        CartItems `json:"items"`
+	Subtotal     float32   `json:"subtotal"`
+	Currency     string    `json:"currency"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// NewCartEvent Function
+func NewCartEvent(eventType string, cart Cart, now time.Time) CartEvent {
+	return CartEvent{
+		EventType:    eventType,
+		Timestamp:    now.UTC(),
+		CartID:       cart.ID,
+		Username:     cart.Username,
+		Items:        cart.Items,
+		Subtotal:     cart.Subtotal,
+		Currency:     cart.Currency,
+		LastActivity: cart.UpdatedAt,
+	}
+}
+
+// EventPublisher delivers cart events. Implementations must be safe for
+// concurrent use.
+type EventPublisher interface {
+	Publish(event CartEvent) error
+}
+
+// NewEventPublisher returns the publisher selected by CART_EVENTS_PUBLISHER:
+// "stdout" (default) or "file", which appends to CART_EVENTS_FILE.
+func NewEventPublisher() EventPublisher {
+	publisher := strings.ToLower(getEnvDefault("CART_EVENTS_PUBLISHER", "stdout"))
+
+	switch publisher {
+	case "file":
+		path := getEnvDefault("CART_EVENTS_FILE", "cart-events.ndjson")
+		log.Println("Publishing cart events to file: ", path)
+		return NewFileEventPublisher(path)
+	case "stdout":
+	default:
+		log.Println("Unknown CART_EVENTS_PUBLISHER; publishing cart events to stdout: ", publisher)
+	}
+
+	return NewWriterEventPublisher(os.Stdout)
+}
+
+// WriterEventPublisher writes each event as a line of JSON to a writer
+type WriterEventPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterEventPublisher Function
+func NewWriterEventPublisher(w io.Writer) *WriterEventPublisher {
+	return &WriterEventPublisher{w: w}
+}
+
+// Publish Function
+func (p *WriterEventPublisher) Publish(event CartEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.w.Write(append(data, '\n'))
+	return err
+}
+
+// FileEventPublisher appends each event as a line of JSON to a file. The file
+// is opened for every event so it can be rotated or removed while running.
+type FileEventPublisher struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileEventPublisher Function
+func NewFileEventPublisher(path string) *FileEventPublisher {
+	return &FileEventPublisher{path: path}
+}
+
+// Publish Function
+func (p *FileEventPublisher) Publish(event CartEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
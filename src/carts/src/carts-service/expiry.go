@@ -0,0 +1,126 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CartSweeper periodically purges carts that have expired and publishes an
+// event for carts that have been abandoned. A cart's age is measured from its
+// last activity (UpdatedAt).
+type CartSweeper struct {
+	store          CartStore
+	publisher      EventPublisher
+	ttl            time.Duration
+	abandonedAfter time.Duration
+	interval       time.Duration
+}
+
+// NewCartSweeper returns a sweeper configured from the environment:
+// CART_TTL_HOURS (default 168) - carts idle this long are deleted, 0 keeps carts forever
+// CART_ABANDONED_HOURS (default 24) - idle carts with a username and items are reported as abandoned, 0 disables
+// CART_SWEEP_INTERVAL_MINUTES (default 15) - how often carts are checked
+func NewCartSweeper(store CartStore, publisher EventPublisher) *CartSweeper {
+	return &CartSweeper{
+		store:          store,
+		publisher:      publisher,
+		ttl:            time.Duration(getEnvInt("CART_TTL_HOURS", 168)) * time.Hour,
+		abandonedAfter: time.Duration(getEnvInt("CART_ABANDONED_HOURS", 24)) * time.Hour,
+		interval:       time.Duration(getEnvInt("CART_SWEEP_INTERVAL_MINUTES", 15)) * time.Minute,
+	}
+}
+
+// Start runs the sweeper in the background until the process exits
+func (s *CartSweeper) Start() {
+	if s.interval <= 0 || (s.ttl <= 0 && s.abandonedAfter <= 0) {
+		log.Println("Cart sweeper disabled")
+		return
+	}
+
+	log.Println("Starting cart sweeper; TTL: ", s.ttl, " abandoned after: ", s.abandonedAfter, " interval: ", s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			s.Sweep(now)
+		}
+	}()
+}
+
+// Sweep checks every cart once against the TTL and abandonment threshold
+func (s *CartSweeper) Sweep(now time.Time) {
+	carts, err := s.store.FindAll()
+	if err != nil {
+		log.Println("Cart sweeper unable to list carts: ", err)
+		return
+	}
+
+	for _, cart := range carts {
+		// Carts written before activity was tracked have no timestamp
+		if cart.UpdatedAt.IsZero() {
+			continue
+		}
+
+		idle := now.Sub(cart.UpdatedAt)
+
+		if s.ttl > 0 && idle >= s.ttl {
+			// Only delete the cart as it was read; a cart changed since is
+			// no longer idle
+			if err := s.store.DeleteVersion(cart.ID, cart.Version); err != nil {
+				if err != ErrCartNotFound && err != ErrCartVersionConflict {
+					log.Println("Cart sweeper unable to delete expired cart: ", cart.ID, err)
+				}
+				continue
+			}
+			releaseReservations(cart.ID, cart.Items)
+			continue
+		}
+
+		if s.abandonedAfter > 0 && idle >= s.abandonedAfter && isAbandonable(cart) {
+			s.publishAbandoned(cart, now)
+		}
+	}
+}
+
+// isAbandonable reports whether an abandoned cart event should be sent for cart
+func isAbandonable(cart Cart) bool {
+	return !cart.AbandonedNotified && len(cart.Username) > 0 && len(cart.Items) > 0
+}
+
+// publishAbandoned publishes the event and then marks the cart so it is only
+// reported once per period of inactivity. The mark keeps the cart's version,
+// so clients holding its ETag can still write to it. If the cart changed in
+// the meantime the mark is dropped, since the cart is no longer abandoned.
+func (s *CartSweeper) publishAbandoned(cart Cart, now time.Time) {
+	if err := s.publisher.Publish(NewCartEvent(EventTypeCartAbandoned, cart, now)); err != nil {
+		log.Println("Cart sweeper unable to publish abandoned cart event: ", cart.ID, err)
+		return
+	}
+
+	if err := s.store.MarkAbandoned(cart.ID, cart.Version); err != nil && err != ErrCartVersionConflict && err != ErrCartNotFound {
+		log.Println("Cart sweeper unable to mark cart abandoned: ", cart.ID, err)
+	}
+}
+
+// getEnvInt returns the integer value of an environment variable or def when
+// it is not set or invalid
+func getEnvInt(key string, def int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || len(value) == 0 {
+		return def
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Println("Invalid value for ", key, "; using default: ", def)
+		return def
+	}
+	return i
+}
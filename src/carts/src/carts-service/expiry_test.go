@@ -0,0 +1,192 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeCatalog serves products and records the reservations carts set, keyed
+// by reservation and product ID. onReserve, when set, runs before each
+// reservation is recorded.
+type fakeCatalog struct {
+	products  map[string]CatalogProduct
+	reserved  map[string]int
+	onReserve func(productID string, reservationID string)
+}
+
+func (c *fakeCatalog) FindProducts(ids []string) (map[string]CatalogProduct, error) {
+	found := map[string]CatalogProduct{}
+	for _, id := range ids {
+		if product, ok := c.products[id]; ok {
+			found[id] = product
+		}
+	}
+	return found, nil
+}
+
+func (c *fakeCatalog) Reserve(productID string, reservationID string, quantity int, ttl time.Duration) error {
+	if c.onReserve != nil {
+		c.onReserve(productID, reservationID)
+	}
+	c.reserved[reservationID+"/"+productID] = quantity
+	return nil
+}
+
+// recordingPublisher keeps the events published
+type recordingPublisher struct {
+	events []CartEvent
+}
+
+func (p *recordingPublisher) Publish(event CartEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+// changingStore changes a cart after the sweeper has read it, as a shopper
+// adding an item during a sweep would
+type changingStore struct {
+	CartStore
+	changeID string
+}
+
+func (s *changingStore) FindAll() ([]Cart, error) {
+	carts, err := s.CartStore.FindAll()
+	if err != nil || len(s.changeID) == 0 {
+		return carts, err
+	}
+	cart, err := s.CartStore.FindByID(s.changeID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.CartStore.Update(cart); err != nil {
+		return nil, err
+	}
+	return carts, nil
+}
+
+func TestCartSweeperSweep(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	items := CartItems{{ProductID: "shirt", Quantity: 2}}
+
+	tests := []struct {
+		name          string
+		cart          Cart
+		changed       bool
+		wantKept      bool
+		wantReleased  map[string]int
+		wantAbandoned bool
+	}{
+		{
+			name:         "expired",
+			cart:         Cart{Username: "ada", Items: items, UpdatedAt: now.Add(-8 * 24 * time.Hour)},
+			wantReleased: map[string]int{"1/shirt": 0},
+		},
+		{
+			name:         "changed after it was read",
+			cart:         Cart{Username: "ada", Items: items, UpdatedAt: now.Add(-8 * 24 * time.Hour)},
+			changed:      true,
+			wantKept:     true,
+			wantReleased: map[string]int{},
+		},
+		{
+			name:          "abandoned",
+			cart:          Cart{Username: "ada", Items: items, UpdatedAt: now.Add(-25 * time.Hour)},
+			wantKept:      true,
+			wantReleased:  map[string]int{},
+			wantAbandoned: true,
+		},
+		{
+			name:         "already reported abandoned",
+			cart:         Cart{Username: "ada", Items: items, UpdatedAt: now.Add(-25 * time.Hour), AbandonedNotified: true},
+			wantKept:     true,
+			wantReleased: map[string]int{},
+		},
+		{
+			name:         "anonymous carts are not reported",
+			cart:         Cart{Items: items, UpdatedAt: now.Add(-25 * time.Hour)},
+			wantKept:     true,
+			wantReleased: map[string]int{},
+		},
+		{
+			name:         "active",
+			cart:         Cart{Username: "ada", Items: items, UpdatedAt: now.Add(-time.Hour)},
+			wantKept:     true,
+			wantReleased: map[string]int{},
+		},
+		{
+			name:         "no activity recorded",
+			cart:         Cart{Username: "ada", Items: items},
+			wantKept:     true,
+			wantReleased: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := &fakeCatalog{reserved: map[string]int{}}
+			saved := productCatalog
+			productCatalog = catalog
+			defer func() { productCatalog = saved }()
+
+			memory := NewMemoryCartStore()
+			cart, err := memory.Create(tt.cart)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			store := &changingStore{CartStore: memory}
+			if tt.changed {
+				store.changeID = cart.ID
+			}
+
+			publisher := &recordingPublisher{}
+			sweeper := &CartSweeper{store: store, publisher: publisher, ttl: 7 * 24 * time.Hour, abandonedAfter: 24 * time.Hour}
+			sweeper.Sweep(now)
+
+			stored, err := memory.FindByID(cart.ID)
+			if kept := err == nil; kept != tt.wantKept {
+				t.Errorf("cart kept = %v, want %v", kept, tt.wantKept)
+			}
+			if !reflect.DeepEqual(catalog.reserved, tt.wantReleased) {
+				t.Errorf("reservations set = %v, want %v", catalog.reserved, tt.wantReleased)
+			}
+			if abandoned := len(publisher.events) > 0; abandoned != tt.wantAbandoned {
+				t.Errorf("abandoned event = %v, want %v", abandoned, tt.wantAbandoned)
+			}
+			if tt.wantAbandoned && !stored.AbandonedNotified {
+				t.Errorf("cart not marked abandoned")
+			}
+			if tt.wantKept && !tt.changed && stored.Version != cart.Version {
+				t.Errorf("Version = %d, want %d so the cart's ETag still matches", stored.Version, cart.Version)
+			}
+		})
+	}
+}
+
+func TestMemoryCartStoreDeleteVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		version int
+		want    error
+	}{
+		{name: "current version", id: "1", version: 1, want: nil},
+		{name: "stale version", id: "1", version: 0, want: ErrCartVersionConflict},
+		{name: "missing cart", id: "2", version: 1, want: ErrCartNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryCartStore()
+			if _, err := store.Create(Cart{}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if err := store.DeleteVersion(tt.id, tt.version); err != tt.want {
+				t.Errorf("DeleteVersion() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
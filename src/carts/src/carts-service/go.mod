@@ -4,6 +4,10 @@ go 1.11
 
 require (
 	github.com/aws/aws-sdk-go v1.44.97
+	github.com/google/uuid v1.1.5
 	github.com/gorilla/mux v1.8.0
 	gopkg.in/yaml.v2 v2.4.0 // indirect
+	shared v0.0.0
 )
+
+replace shared => ../../../shared
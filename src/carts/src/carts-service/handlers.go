@@ -5,14 +5,16 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/lambda"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Index Handler
@@ -27,12 +29,7 @@ func CartIndex(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 
-	var values []Cart
-	for _, value := range carts {
-		values = append(values, value)
-	}
-
-	if err := json.NewEncoder(w).Encode(values); err != nil {
+	if err := json.NewEncoder(w).Encode(RepoFindAllCarts()); err != nil {
 		panic(err)
 	}
 }
@@ -44,7 +41,120 @@ func CartShowByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	cartID := vars["cartID"]
 
-	if err := json.NewEncoder(w).Encode(RepoFindCartByID(cartID)); err != nil {
+	cart := RepoFindCartByID(cartID)
+	if len(cart.ID) > 0 {
+		w.Header().Set("ETag", cartETag(cart))
+
+		// Reflect current catalog prices without changing the stored cart
+		if err := PriceCart(&cart, productCatalog); err != nil {
+			log.Println("CartShowByID unable to price cart: ", err)
+		}
+	}
+
+	if err := json.NewEncoder(w).Encode(cart); err != nil {
+		panic(err)
+	}
+}
+
+// CartEvents Handler
+// Streams the cart as Server-Sent Events: the current cart when the client
+// connects, then a "cart" event each time the cart changes and a "deleted"
+// event if it is removed. Comment lines are sent as heartbeats while the cart
+// is idle.
+func CartEvents(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	cartID := vars["cartID"]
+
+	// Subscribe before reading the cart so no change is missed in between
+	changes, unsubscribe := cartBroker.Subscribe(cartID)
+	defer unsubscribe()
+
+	cart := RepoFindCartByID(cartID)
+	if len(cart.ID) == 0 {
+		http.Error(w, ErrCartNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	if err := PriceCart(&cart, productCatalog); err != nil {
+		log.Println("CartEvents unable to price cart: ", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	version := cart.Version
+	if err := writeCartEvent(w, "cart", cart); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(cartSyncHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case change, ok := <-changes:
+			if !ok {
+				// The broker is shutting down
+				return
+			}
+			if change.Deleted {
+				writeCartEvent(w, "deleted", change.Cart)
+				flusher.Flush()
+				return
+			}
+			if change.Cart.Version <= version {
+				continue
+			}
+			version = change.Cart.Version
+			if err := writeCartEvent(w, "cart", change.Cart); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeCartEvent writes the cart as a Server-Sent Event whose ID is the cart
+// version
+func writeCartEvent(w io.Writer, event string, cart Cart) error {
+	data, err := json.Marshal(cart)
+	if err != nil {
+		log.Println("writeCartEvent error: ", err)
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", event, cart.Version, data)
+	return err
+}
+
+// CartIndexByUsername Handler
+func CartIndexByUsername(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+	username := vars["username"]
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(RepoFindCartsByUsername(username)); err != nil {
 		panic(err)
 	}
 }
@@ -71,18 +181,25 @@ func CartUpdate(w http.ResponseWriter, r *http.Request) {
 		if err := json.NewEncoder(w).Encode(err); err != nil {
 			panic(err)
 		}
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	vars := mux.Vars(r)
 	cartID := vars["cartID"]
 
-	t := RepoUpdateCart(cartID, cart)
-
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(t); err != nil {
-		panic(err)
+	t, err := RepoUpdateCart(cartID, cart, expectedVersion)
+	if err != nil {
+		writeCartError(w, err)
+		return
 	}
+
+	writeCart(w, t, http.StatusCreated)
 }
 
 //CartCreate Func
@@ -107,18 +224,235 @@ func CartCreate(w http.ResponseWriter, r *http.Request) {
 		if err := json.NewEncoder(w).Encode(err); err != nil {
 			panic(err)
 		}
+		return
 	}
 
-	t := RepoCreateCart(cart)
+	t, err := RepoCreateCart(cart)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, t, http.StatusCreated)
+}
+
+// CartItemAdd Func
+func CartItemAdd(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var item CartItem
+	if err := decodeRequestBody(r, &item); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	cart, err := RepoMutateCart(vars["cartID"], expectedVersion, func(c *Cart) error {
+		return c.AddItem(item)
+	})
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, cart, http.StatusCreated)
+}
+
+// CartItemUpdate Func
+func CartItemUpdate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var quantity CartItemQuantity
+	if err := decodeRequestBody(r, &quantity); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	cart, err := RepoMutateCart(vars["cartID"], expectedVersion, func(c *Cart) error {
+		return c.SetItemQuantity(vars["productID"], quantity.Quantity)
+	})
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, cart, http.StatusOK)
+}
+
+// CartItemDelete Func
+func CartItemDelete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	cart, err := RepoMutateCart(vars["cartID"], expectedVersion, func(c *Cart) error {
+		return c.RemoveItem(vars["productID"])
+	})
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, cart, http.StatusOK)
+}
+
+// CartPromotionAdd Func
+func CartPromotionAdd(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var promotion PromotionCode
+	if err := decodeRequestBody(r, &promotion); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	cart, err := RepoApplyPromotion(vars["cartID"], promotion.Code, expectedVersion)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, cart, http.StatusCreated)
+}
+
+// CartPromotionDelete Func
+func CartPromotionDelete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	cart, err := RepoRemovePromotion(vars["cartID"], vars["code"], expectedVersion)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, cart, http.StatusOK)
+}
+
+// CartMerge Func
+func CartMerge(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	cart, err := RepoMergeCarts(vars["cartID"], vars["otherID"], expectedVersion)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCart(w, cart, http.StatusOK)
+}
+
+// CartCheckout Func
+func CartCheckout(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var checkout CheckoutRequest
+	if err := decodeRequestBody(r, &checkout); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	order, err := CheckoutCart(vars["cartID"], checkout)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(t); err != nil {
+	if err := json.NewEncoder(w).Encode(CheckoutResponse{OrderID: order.ID, Order: order}); err != nil {
 		panic(err)
 	}
 }
 
-//Sign a payload for Amazon Pay - delegates to a Lambda function for doing this.
+// SignResponse Struct - matches the response of the Amazon Pay signing Lambda
+// function, which the web UI reads the signature from
+type SignResponse struct {
+	StatusCode int              `json:"statusCode"`
+	Body       SignResponseBody `json:"body"`
+}
+
+// SignResponseBody Struct
+type SignResponseBody struct {
+	Signature string          `json:"Signature"`
+	Payload   json.RawMessage `json:"Payload"`
+}
+
+//Sign a payload for Amazon Pay - delegates to the configured PayloadSigner.
 func SignAmazonPayPayload(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
 	if (*r).Method == "OPTIONS" {
@@ -126,41 +460,142 @@ func SignAmazonPayPayload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	awsSession := session.Must(session.NewSessionWithOptions(session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-	}))
-
-	client := lambda.New(awsSession)
+	if payloadSigner == nil {
+		http.Error(w, ErrSignerNotConfigured.Error(), http.StatusServiceUnavailable)
+		return
+	}
 
 	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
 	if err != nil {
-		panic(err)
+		http.Error(w, "Unable to read request body", http.StatusBadRequest)
+		return
 	}
 	if err := r.Body.Close(); err != nil {
-		panic(err)
+		log.Println("SignAmazonPayPayload error closing request body: ", err)
 	}
 
-	var requestBody map[string]interface{}
-	json.Unmarshal(body, &requestBody)
+	if !json.Valid(body) {
+		http.Error(w, "Payload must be valid JSON", http.StatusBadRequest)
+		return
+	}
 
-	result, err := client.Invoke(&lambda.InvokeInput{FunctionName: aws.String("AmazonPaySigningLambda"), Payload: body})
+	signature, err := payloadSigner.Sign(body)
 	if err != nil {
-		panic(err)
+		if err == ErrSignerUnavailable {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		} else {
+			log.Println("SignAmazonPayPayload error: ", err)
+			http.Error(w, "Internal error signing payload", http.StatusInternalServerError)
+		}
+		return
 	}
 
-	var responsePayload map[string]interface{}
-	json.Unmarshal(result.Payload, &responsePayload)
-
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(responsePayload); err != nil {
+	response := SignResponse{
+		StatusCode: http.StatusOK,
+		Body:       SignResponseBody{Signature: signature, Payload: body},
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Println("SignAmazonPayPayload error writing response: ", err)
+	}
+}
+
+// decodeRequestBody reads a JSON request body into v
+func decodeRequestBody(r *http.Request, v interface{}) error {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		return err
+	}
+	if err := r.Body.Close(); err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// writeUnprocessable responds with 422 and the error that caused it
+func writeUnprocessable(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(422) // unprocessable entity
+	if err := json.NewEncoder(w).Encode(err); err != nil {
+		panic(err)
+	}
+}
+
+// writeCart responds with the cart and its ETag
+func writeCart(w http.ResponseWriter, cart Cart, status int) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("ETag", cartETag(cart))
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(cart); err != nil {
 		panic(err)
 	}
 }
 
+// writeCartError maps errors from cart operations to HTTP responses
+func writeCartError(w http.ResponseWriter, err error) {
+	if _, ok := err.(*ProductNotFoundError); ok {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if _, ok := err.(*ValidationError); ok {
+		writeUnprocessable(w, err)
+		return
+	}
+	if stockErr, ok := err.(*OutOfStockError); ok {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusConflict)
+		if err := json.NewEncoder(w).Encode(stockErr); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if rejected, ok := err.(*OrderRejectedError); ok {
+		http.Error(w, rejected.Message, rejected.Status)
+		return
+	}
+
+	switch err {
+	case ErrCartNotFound, ErrCartItemNotFound, ErrPromotionNotInCart:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrCartVersionConflict:
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case ErrCartChangedDuringCheckout:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrInvalidQuantity, ErrMissingProductID, ErrMergeSameCart, ErrCartEmpty,
+		ErrMissingPromotionCode, ErrPromotionNotFound, ErrPromotionNotActive, ErrPromotionUsedUp, ErrPromotionNotApplicable:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case ErrCatalogUnavailable, ErrOrderServiceUnavailable:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "Internal error updating cart", http.StatusInternalServerError)
+	}
+}
+
+// cartETag returns the strong entity tag for the current version of a cart
+func cartETag(cart Cart) string {
+	return strconv.Quote(strconv.Itoa(cart.Version))
+}
+
+// parseIfMatch returns the cart version required by the If-Match header, or
+// zero when the header is absent or matches any version.
+func parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if len(value) == 0 || value == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if err != nil || version < 1 {
+		return 0, errors.New("If-Match must be an ETag returned by this service")
+	}
+	return version, nil
+}
+
 // enableCors
 func enableCors(w *http.ResponseWriter) {
 	(*w).Header().Set("Access-Control-Allow-Origin", "*")
-	(*w).Header().Set("Access-Control-Allow-Methods", "POST, PUT, GET, OPTIONS")
-	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, PUT, PATCH, DELETE, GET, OPTIONS")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match")
+	(*w).Header().Set("Access-Control-Expose-Headers", "ETag")
 }
@@ -0,0 +1,153 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// List types. A username has at most one wishlist and one saved-for-later
+// list, and any number of custom lists.
+const (
+	ListTypeWishlist      = "WISHLIST"
+	ListTypeSavedForLater = "SAVED_FOR_LATER"
+	ListTypeCustom        = "CUSTOM"
+)
+
+// Errors returned by list operations
+var (
+	ErrListNotFound        = errors.New("List not found")
+	ErrListVersionConflict = errors.New("List has been modified since it was read")
+	ErrListItemNotFound    = errors.New("List item not found")
+	ErrListExists          = errors.New("A list of this type already exists for the username")
+	ErrInvalidListType     = errors.New("List type must be WISHLIST, SAVED_FOR_LATER or CUSTOM")
+	ErrMissingListUsername = errors.New("List username is required")
+	ErrMissingListName     = errors.New("Custom lists require a name")
+	ErrListNotShared       = errors.New("List is not shared")
+)
+
+// List Struct - a named list of products kept for a username outside of a cart
+type List struct {
+	ID         string    `json:"id" yaml:"id"`
+	Username   string    `json:"username" yaml:"username"`
+	Name       string    `json:"name" yaml:"name"`
+	Type       string    `json:"type" yaml:"type"`
+	Items      ListItems `json:"items" yaml:"items"`
+	ShareToken string    `json:"share_token,omitempty" yaml:"share_token,omitempty" dynamodbav:"share_token,omitempty"` // omitted when empty so it can key an index
+	CreatedAt  time.Time `json:"created_at" yaml:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" yaml:"updated_at"`
+	Version    int       `json:"version" yaml:"version"`
+}
+
+// ListItem Struct - a product reference. Name, price and discontinued are
+// filled in from the products service whenever the list is read or changed.
+type ListItem struct {
+	ProductID    string    `json:"product_id" yaml:"product_id"`
+	ProductName  string    `json:"product_name" yaml:"product_name"`
+	Quantity     int       `json:"quantity" yaml:"quantity"`
+	Price        float32   `json:"price" yaml:"price" dynamodbav:"-"`
+	Discontinued bool      `json:"discontinued" yaml:"discontinued" dynamodbav:"-"`
+	AddedAt      time.Time `json:"added_at" yaml:"added_at"`
+}
+
+// ListItems Array
+type ListItems []ListItem
+
+// ListMoveRequest Struct - request body for moving an item between a list and
+// a cart
+type ListMoveRequest struct {
+	CartID string `json:"cart_id,omitempty" yaml:"cart_id,omitempty"`
+	ListID string `json:"list_id,omitempty" yaml:"list_id,omitempty"`
+}
+
+// Validate checks the fields a client supplies when creating a list and
+// normalizes the list type
+func (l *List) Validate() error {
+	if len(l.Username) == 0 {
+		return ErrMissingListUsername
+	}
+
+	l.Type = strings.ToUpper(strings.TrimSpace(l.Type))
+	if len(l.Type) == 0 {
+		l.Type = ListTypeCustom
+	}
+
+	switch l.Type {
+	case ListTypeWishlist:
+		if len(l.Name) == 0 {
+			l.Name = "Wishlist"
+		}
+	case ListTypeSavedForLater:
+		if len(l.Name) == 0 {
+			l.Name = "Saved for later"
+		}
+	case ListTypeCustom:
+		if len(strings.TrimSpace(l.Name)) == 0 {
+			return ErrMissingListName
+		}
+	default:
+		return ErrInvalidListType
+	}
+	return nil
+}
+
+// AddItem adds item to the list, adding to the quantity of an existing line
+// for the same product. Items without a quantity are added once.
+func (l *List) AddItem(item ListItem, now time.Time) error {
+	if len(item.ProductID) == 0 {
+		return ErrMissingProductID
+	}
+	if item.Quantity < 0 {
+		return ErrInvalidQuantity
+	}
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+
+	for i := range l.Items {
+		if l.Items[i].ProductID == item.ProductID {
+			l.Items[i].Quantity += item.Quantity
+			return nil
+		}
+	}
+
+	l.Items = append(l.Items, ListItem{ProductID: item.ProductID, Quantity: item.Quantity, AddedAt: now})
+	return nil
+}
+
+// RemoveItem removes the line for productID and returns it
+func (l *List) RemoveItem(productID string) (ListItem, error) {
+	for i := range l.Items {
+		if l.Items[i].ProductID == productID {
+			item := l.Items[i]
+			l.Items = append(l.Items[:i], l.Items[i+1:]...)
+			return item, nil
+		}
+	}
+
+	return ListItem{}, ErrListItemNotFound
+}
+
+// SubtractItem takes quantity off the line for productID, removing the line
+// when nothing is left
+func (l *List) SubtractItem(productID string, quantity int) error {
+	for i := range l.Items {
+		if l.Items[i].ProductID == productID {
+			l.Items[i].Quantity -= quantity
+			if l.Items[i].Quantity <= 0 {
+				l.Items = append(l.Items[:i], l.Items[i+1:]...)
+			}
+			return nil
+		}
+	}
+
+	return ErrListItemNotFound
+}
+
+// singletonListType reports whether a username may have only one list of t
+func singletonListType(t string) bool {
+	return t == ListTypeWishlist || t == ListTypeSavedForLater
+}
@@ -0,0 +1,312 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ListIndexByUsername Handler
+func ListIndexByUsername(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(RepoFindListsByUsername(vars["username"])); err != nil {
+		panic(err)
+	}
+}
+
+// ListShowByID Handler
+func ListShowByID(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	list, err := RepoFindListByID(vars["listID"])
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, list, http.StatusOK)
+}
+
+// SharedListShow Handler
+func SharedListShow(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	list, err := RepoFindSharedList(vars["token"])
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, list, http.StatusOK)
+}
+
+// ListCreate Func
+func ListCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var list List
+	if err := decodeRequestBody(r, &list); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	t, err := RepoCreateList(list)
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, t, http.StatusCreated)
+}
+
+// ListUpdate Func
+func ListUpdate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var list List
+	if err := decodeRequestBody(r, &list); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	t, err := RepoUpdateList(vars["listID"], list, expectedVersion)
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, t, http.StatusOK)
+}
+
+// ListDelete Func
+func ListDelete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	if err := RepoDeleteList(vars["listID"]); err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListItemAdd Func
+func ListItemAdd(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var item ListItem
+	if err := decodeRequestBody(r, &item); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	list, err := RepoMutateList(vars["listID"], expectedVersion, func(l *List) error {
+		return l.AddItem(item, time.Now().UTC())
+	})
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, list, http.StatusCreated)
+}
+
+// ListItemDelete Func
+func ListItemDelete(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	list, err := RepoMutateList(vars["listID"], expectedVersion, func(l *List) error {
+		_, err := l.RemoveItem(vars["productID"])
+		return err
+	})
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, list, http.StatusOK)
+}
+
+// ListItemMoveToCart Func
+func ListItemMoveToCart(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var move ListMoveRequest
+	if err := decodeRequestBody(r, &move); err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+	if len(move.CartID) == 0 {
+		http.Error(w, "cart_id is required", http.StatusUnprocessableEntity)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	cart, err := RepoMoveListItemToCart(vars["listID"], vars["productID"], move.CartID)
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeCart(w, cart, http.StatusOK)
+}
+
+// CartItemMoveToList Func
+func CartItemMoveToList(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// The body is optional; without a list_id the item is saved for later
+	var move ListMoveRequest
+	if r.ContentLength != 0 {
+		if err := decodeRequestBody(r, &move); err != nil {
+			writeUnprocessable(w, err)
+			return
+		}
+	}
+
+	vars := mux.Vars(r)
+
+	list, err := RepoMoveCartItemToList(vars["cartID"], vars["productID"], move.ListID)
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, list, http.StatusOK)
+}
+
+// ListShare Func
+func ListShare(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	list, err := RepoShareList(vars["listID"])
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, list, http.StatusOK)
+}
+
+// ListUnshare Func
+func ListUnshare(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	list, err := RepoUnshareList(vars["listID"])
+	if err != nil {
+		writeListError(w, err)
+		return
+	}
+
+	writeList(w, list, http.StatusOK)
+}
+
+// writeList responds with the list and its ETag
+func writeList(w http.ResponseWriter, list List, status int) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(list.Version)))
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		panic(err)
+	}
+}
+
+// writeListError maps errors from list operations to HTTP responses. Errors
+// from the cart side of a move are handled by writeCartError.
+func writeListError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrListNotFound, ErrListItemNotFound, ErrListNotShared:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrListVersionConflict:
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case ErrListExists:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrInvalidListType, ErrMissingListUsername, ErrMissingListName:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		writeCartError(w, err)
+	}
+}
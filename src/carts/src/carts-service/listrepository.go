@@ -0,0 +1,360 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+)
+
+var listStore ListStore
+
+// Init
+func init() {
+	listStore = NewListStore()
+}
+
+// RepoFindListByID Function
+func RepoFindListByID(id string) (List, error) {
+	list, err := listStore.FindByID(id)
+	if err != nil {
+		if err != ErrListNotFound {
+			log.Println("RepoFindListByID error: ", err)
+		}
+		return List{}, err
+	}
+
+	checkListItems(&list, productCatalog)
+	return list, nil
+}
+
+// RepoFindListsByUsername Function
+func RepoFindListsByUsername(username string) []List {
+	values, err := listStore.FindByUsername(username)
+	if err != nil {
+		log.Println("RepoFindListsByUsername error: ", err)
+		return []List{}
+	}
+
+	for i := range values {
+		checkListItems(&values[i], productCatalog)
+	}
+	return values
+}
+
+// RepoFindSharedList Function
+// Returns the list shared with token without its username
+func RepoFindSharedList(token string) (List, error) {
+	list, err := listStore.FindByShareToken(token)
+	if err != nil {
+		if err != ErrListNotFound {
+			log.Println("RepoFindSharedList error: ", err)
+		}
+		return List{}, err
+	}
+
+	list.Username = ""
+	checkListItems(&list, productCatalog)
+	return list, nil
+}
+
+// RepoCreateList Function
+// A username can only have one wishlist and one saved-for-later list.
+func RepoCreateList(t List) (List, error) {
+	if err := t.Validate(); err != nil {
+		return List{}, err
+	}
+
+	if singletonListType(t.Type) {
+		existing, err := listStore.FindByUsername(t.Username)
+		if err != nil {
+			log.Println("RepoCreateList error: ", err)
+			return List{}, err
+		}
+		for _, l := range existing {
+			if l.Type == t.Type {
+				return List{}, ErrListExists
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	items := t.Items
+	t.Items = ListItems{}
+	for _, item := range items {
+		if err := t.AddItem(item, now); err != nil {
+			return List{}, err
+		}
+	}
+	// Lists are shared through RepoShareList so tokens are never client chosen
+	t.ShareToken = ""
+	t.CreatedAt = now
+	t.UpdatedAt = now
+	checkListItems(&t, productCatalog)
+
+	created, err := listStore.Create(t)
+	if err != nil {
+		log.Println("RepoCreateList error: ", err)
+		return List{}, err
+	}
+	return created, nil
+}
+
+// RepoUpdateList Function
+// Replaces the name and items of a list. The username, type and share token
+// can't be changed, and items keep the time they were first added.
+func RepoUpdateList(id string, list List, expectedVersion int) (List, error) {
+	return RepoMutateList(id, expectedVersion, func(existing *List) error {
+		if len(list.Name) > 0 {
+			existing.Name = list.Name
+		}
+
+		addedAt := make(map[string]time.Time, len(existing.Items))
+		for _, item := range existing.Items {
+			addedAt[item.ProductID] = item.AddedAt
+		}
+
+		now := time.Now().UTC()
+		existing.Items = ListItems{}
+		for _, item := range list.Items {
+			if err := existing.AddItem(item, now); err != nil {
+				return err
+			}
+		}
+		for i := range existing.Items {
+			if t, ok := addedAt[existing.Items[i].ProductID]; ok {
+				existing.Items[i].AddedAt = t
+			}
+		}
+		return nil
+	})
+}
+
+// RepoMutateList Function
+// Loads the list, applies mutate to it and stores the result, retrying on a
+// conflicting write in the same way as RepoMutateCart.
+func RepoMutateList(id string, expectedVersion int, mutate func(*List) error) (List, error) {
+	for attempt := 0; ; attempt++ {
+		list, err := listStore.FindByID(id)
+		if err != nil {
+			if err != ErrListNotFound {
+				log.Println("RepoMutateList error: ", err)
+			}
+			return List{}, err
+		}
+
+		if expectedVersion > 0 && list.Version != expectedVersion {
+			return List{}, ErrListVersionConflict
+		}
+
+		if err := mutate(&list); err != nil {
+			return List{}, err
+		}
+		list.ID = id
+		list.UpdatedAt = time.Now().UTC()
+		checkListItems(&list, productCatalog)
+
+		updated, err := listStore.Update(list)
+		if err != ErrListVersionConflict || expectedVersion > 0 || attempt+1 >= maxUpdateAttempts {
+			if err != nil && err != ErrListNotFound && err != ErrListVersionConflict {
+				log.Println("RepoMutateList error: ", err)
+			}
+			return updated, err
+		}
+	}
+}
+
+// RepoDeleteList Function
+func RepoDeleteList(id string) error {
+	err := listStore.Delete(id)
+	if err != nil && err != ErrListNotFound {
+		log.Println("RepoDeleteList error: ", err)
+	}
+	return err
+}
+
+// RepoShareList Function
+// Gives the list a share token if it doesn't already have one
+func RepoShareList(id string) (List, error) {
+	return RepoMutateList(id, 0, func(list *List) error {
+		if len(list.ShareToken) > 0 {
+			return nil
+		}
+		token, err := newShareToken()
+		if err != nil {
+			return err
+		}
+		list.ShareToken = token
+		return nil
+	})
+}
+
+// RepoUnshareList Function
+// Removes the list's share token so links to it stop working
+func RepoUnshareList(id string) (List, error) {
+	return RepoMutateList(id, 0, func(list *List) error {
+		if len(list.ShareToken) == 0 {
+			return ErrListNotShared
+		}
+		list.ShareToken = ""
+		return nil
+	})
+}
+
+// RepoMoveListItemToCart Function
+// Adds a list item to the cart with the same checks and stock reservation as
+// any other cart item, then removes it from the list.
+func RepoMoveListItemToCart(listID string, productID string, cartID string) (Cart, error) {
+	list, err := listStore.FindByID(listID)
+	if err != nil {
+		return Cart{}, err
+	}
+
+	var item *ListItem
+	for i := range list.Items {
+		if list.Items[i].ProductID == productID {
+			item = &list.Items[i]
+			break
+		}
+	}
+	if item == nil {
+		return Cart{}, ErrListItemNotFound
+	}
+
+	cart, err := RepoMutateCart(cartID, 0, func(c *Cart) error {
+		return c.AddItem(CartItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	})
+	if err != nil {
+		return Cart{}, err
+	}
+
+	_, err = RepoMutateList(listID, 0, func(l *List) error {
+		_, err := l.RemoveItem(productID)
+		return err
+	})
+	if err != nil && err != ErrListItemNotFound {
+		log.Println("RepoMoveListItemToCart unable to remove item from list: ", listID, productID, err)
+	}
+
+	return cart, nil
+}
+
+// RepoMoveCartItemToList Function
+// Moves a cart item into a list, releasing the stock it had reserved. When no
+// list is given the item goes to the cart owner's saved-for-later list, which
+// is created if needed.
+func RepoMoveCartItemToList(cartID string, productID string, listID string) (List, error) {
+	cart, err := cartStore.FindByID(cartID)
+	if err != nil {
+		return List{}, err
+	}
+
+	var item *CartItem
+	for i := range cart.Items {
+		if cart.Items[i].ProductID == productID {
+			item = &cart.Items[i]
+			break
+		}
+	}
+	if item == nil {
+		return List{}, ErrCartItemNotFound
+	}
+
+	if len(listID) == 0 {
+		saved, err := savedForLaterList(cart.Username)
+		if err != nil {
+			return List{}, err
+		}
+		listID = saved.ID
+	}
+
+	listItem := ListItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	list, err := RepoMutateList(listID, 0, func(l *List) error {
+		return l.AddItem(listItem, time.Now().UTC())
+	})
+	if err != nil {
+		return List{}, err
+	}
+
+	_, err = RepoMutateCart(cartID, 0, func(c *Cart) error {
+		return c.RemoveItem(productID)
+	})
+	if err != nil && err != ErrCartItemNotFound {
+		// Take the item back out of the list so it isn't in both places
+		if _, err := RepoMutateList(listID, 0, func(l *List) error {
+			return l.SubtractItem(listItem.ProductID, listItem.Quantity)
+		}); err != nil {
+			log.Println("RepoMoveCartItemToList unable to restore list: ", listID, productID, err)
+		}
+		return List{}, err
+	}
+
+	return list, nil
+}
+
+// savedForLaterList returns the username's saved-for-later list, creating it
+// if it doesn't exist yet
+func savedForLaterList(username string) (List, error) {
+	if len(username) == 0 {
+		return List{}, ErrMissingListUsername
+	}
+
+	lists, err := listStore.FindByUsername(username)
+	if err != nil {
+		log.Println("savedForLaterList error: ", err)
+		return List{}, err
+	}
+	for _, l := range lists {
+		if l.Type == ListTypeSavedForLater {
+			return l, nil
+		}
+	}
+
+	return RepoCreateList(List{Username: username, Type: ListTypeSavedForLater})
+}
+
+// checkListItems fills in the name and current price of each item from the
+// products service and flags items whose product no longer exists. Names are
+// stored with the list so discontinued items can still be shown. Lists are
+// left unchecked when the products service can't be reached.
+func checkListItems(list *List, catalog ProductCatalog) {
+	if list.Items == nil {
+		list.Items = ListItems{}
+	}
+	if len(list.Items) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := catalog.FindProducts(ids)
+	if err != nil {
+		log.Println("checkListItems error looking up products: ", err)
+		return
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		product, ok := products[item.ProductID]
+		item.Discontinued = !ok
+		if ok {
+			item.ProductName = product.Name
+			item.Price = product.Price
+		}
+	}
+}
+
+// newShareToken returns a random token that is impractical to guess
+func newShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
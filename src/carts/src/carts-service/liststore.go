@@ -0,0 +1,179 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DynamoDB table name for lists passed via environment. When empty, lists are
+// kept in memory.
+var ddbTableLists = os.Getenv("DDB_TABLE_LISTS")
+
+// ListStore persists lists. Like CartStore, implementations must be safe for
+// concurrent use and stamp every write with a new Version.
+type ListStore interface {
+	// FindByID returns the list for id or ErrListNotFound
+	FindByID(id string) (List, error)
+	// FindByUsername returns the lists belonging to username
+	FindByUsername(username string) ([]List, error)
+	// FindByShareToken returns the list shared with token or ErrListNotFound
+	FindByShareToken(token string) (List, error)
+	// Create assigns a new ID and the first version to list and persists it
+	Create(list List) (List, error)
+	// Update replaces an existing list if list.Version is still the stored
+	// version, otherwise it returns ErrListVersionConflict
+	Update(list List) (List, error)
+	// Delete removes the list for id or returns ErrListNotFound
+	Delete(id string) error
+}
+
+// NewListStore returns a DynamoDB backed store when a lists table is
+// configured, otherwise an in-memory store.
+func NewListStore() ListStore {
+	if len(ddbTableLists) > 0 {
+		log.Println("Using DynamoDB list store with table: ", ddbTableLists)
+		return NewDynamoListStore(dynamoClient, ddbTableLists)
+	}
+
+	log.Println("Using in-memory list store")
+	return NewMemoryListStore()
+}
+
+// MemoryListStore keeps lists in process memory. Lists are lost on restart.
+type MemoryListStore struct {
+	mu           sync.RWMutex
+	currentID    int
+	lists        map[string]List
+	byUsername   map[string]map[string]bool
+	byShareToken map[string]string
+}
+
+// NewMemoryListStore Function
+func NewMemoryListStore() *MemoryListStore {
+	return &MemoryListStore{
+		lists:        map[string]List{},
+		byUsername:   map[string]map[string]bool{},
+		byShareToken: map[string]string{},
+	}
+}
+
+// FindByID Function
+func (s *MemoryListStore) FindByID(id string) (List, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list, ok := s.lists[id]
+	if !ok {
+		return List{}, ErrListNotFound
+	}
+	return copyList(list), nil
+}
+
+// FindByUsername Function
+func (s *MemoryListStore) FindByUsername(username string) ([]List, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]List, 0, len(s.byUsername[username]))
+	for id := range s.byUsername[username] {
+		values = append(values, copyList(s.lists[id]))
+	}
+	return values, nil
+}
+
+// FindByShareToken Function
+func (s *MemoryListStore) FindByShareToken(token string) (List, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byShareToken[token]
+	if !ok {
+		return List{}, ErrListNotFound
+	}
+	return copyList(s.lists[id]), nil
+}
+
+// Create Function
+func (s *MemoryListStore) Create(list List) (List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.currentID++
+	list.ID = strconv.Itoa(s.currentID)
+	list.Version = 1
+	s.lists[list.ID] = copyList(list)
+	s.index(list)
+	return list, nil
+}
+
+// Update Function
+func (s *MemoryListStore) Update(list List) (List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.lists[list.ID]
+	if !ok {
+		return List{}, ErrListNotFound
+	}
+	if existing.Version != list.Version {
+		return List{}, ErrListVersionConflict
+	}
+
+	list.Version++
+	s.unindex(existing)
+	s.lists[list.ID] = copyList(list)
+	s.index(list)
+	return list, nil
+}
+
+// Delete Function
+func (s *MemoryListStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.lists[id]
+	if !ok {
+		return ErrListNotFound
+	}
+
+	s.unindex(existing)
+	delete(s.lists, id)
+	return nil
+}
+
+// index adds the list to the username and share token indexes. Callers must
+// hold the lock.
+func (s *MemoryListStore) index(list List) {
+	if s.byUsername[list.Username] == nil {
+		s.byUsername[list.Username] = map[string]bool{}
+	}
+	s.byUsername[list.Username][list.ID] = true
+	if len(list.ShareToken) > 0 {
+		s.byShareToken[list.ShareToken] = list.ID
+	}
+}
+
+// unindex removes the list from the indexes. Callers must hold the lock.
+func (s *MemoryListStore) unindex(list List) {
+	ids := s.byUsername[list.Username]
+	delete(ids, list.ID)
+	if len(ids) == 0 {
+		delete(s.byUsername, list.Username)
+	}
+	delete(s.byShareToken, list.ShareToken)
+}
+
+// copyList returns a list that does not share its items with l
+func copyList(l List) List {
+	if l.Items != nil {
+		items := make(ListItems, len(l.Items))
+		copy(items, l.Items)
+		l.Items = items
+	}
+	return l
+}
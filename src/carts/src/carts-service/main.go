@@ -4,9 +4,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
@@ -15,6 +19,31 @@ func main() {
 		port = "80"
 	}
 
+	NewCartSweeper(cartStore, NewEventPublisher()).Start()
+
 	router := NewRouter()
-	log.Fatal(http.ListenAndServe(":" + port, router))
-}
\ No newline at end of file
+	server := &http.Server{Addr: ":" + port, Handler: router}
+
+	// On SIGINT or SIGTERM, end cart event streams and let other requests finish
+	done := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+
+		log.Println("Shutting down")
+		cartBroker.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Println("Error shutting down: ", err)
+		}
+		close(done)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+}
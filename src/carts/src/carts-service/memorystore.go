@@ -0,0 +1,195 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"strconv"
+	"sync"
+)
+
+// MemoryCartStore keeps carts in process memory. Carts are lost on restart.
+type MemoryCartStore struct {
+	mu         sync.RWMutex
+	currentID  int
+	carts      map[string]Cart
+	byUsername map[string]map[string]bool
+}
+
+// NewMemoryCartStore Function
+func NewMemoryCartStore() *MemoryCartStore {
+	return &MemoryCartStore{
+		carts:      map[string]Cart{},
+		byUsername: map[string]map[string]bool{},
+	}
+}
+
+// FindAll Function
+func (s *MemoryCartStore) FindAll() ([]Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]Cart, 0, len(s.carts))
+	for _, value := range s.carts {
+		values = append(values, copyCart(value))
+	}
+	return values, nil
+}
+
+// FindByID Function
+func (s *MemoryCartStore) FindByID(id string) (Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cart, ok := s.carts[id]
+	if !ok {
+		return Cart{}, ErrCartNotFound
+	}
+	return copyCart(cart), nil
+}
+
+// FindByUsername Function
+func (s *MemoryCartStore) FindByUsername(username string) ([]Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]Cart, 0, len(s.byUsername[username]))
+	for id := range s.byUsername[username] {
+		values = append(values, copyCart(s.carts[id]))
+	}
+	return values, nil
+}
+
+// Create Function
+func (s *MemoryCartStore) Create(cart Cart) (Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.currentID++
+	cart.ID = strconv.Itoa(s.currentID)
+	cart.Version = 1
+	s.carts[cart.ID] = copyCart(cart)
+	s.index(cart)
+	return cart, nil
+}
+
+// Update Function
+func (s *MemoryCartStore) Update(cart Cart) (Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.carts[cart.ID]
+	if !ok {
+		return Cart{}, ErrCartNotFound
+	}
+	if existing.Version != cart.Version {
+		return Cart{}, ErrCartVersionConflict
+	}
+
+	cart.Version++
+	s.unindex(existing)
+	s.carts[cart.ID] = copyCart(cart)
+	s.index(cart)
+	return cart, nil
+}
+
+// Delete Function
+func (s *MemoryCartStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.carts[id]
+	if !ok {
+		return ErrCartNotFound
+	}
+
+	s.unindex(existing)
+	delete(s.carts, id)
+	return nil
+}
+
+// DeleteVersion Function
+func (s *MemoryCartStore) DeleteVersion(id string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.carts[id]
+	if !ok {
+		return ErrCartNotFound
+	}
+	if existing.Version != version {
+		return ErrCartVersionConflict
+	}
+
+	s.unindex(existing)
+	delete(s.carts, id)
+	return nil
+}
+
+// MarkAbandoned Function
+func (s *MemoryCartStore) MarkAbandoned(id string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.carts[id]
+	if !ok {
+		return ErrCartNotFound
+	}
+	if existing.Version != version {
+		return ErrCartVersionConflict
+	}
+
+	existing.AbandonedNotified = true
+	s.carts[id] = existing
+	return nil
+}
+
+// index adds the cart to the username index. Callers must hold the lock.
+func (s *MemoryCartStore) index(cart Cart) {
+	if len(cart.Username) == 0 {
+		return
+	}
+	if s.byUsername[cart.Username] == nil {
+		s.byUsername[cart.Username] = map[string]bool{}
+	}
+	s.byUsername[cart.Username][cart.ID] = true
+}
+
+// unindex removes the cart from the username index. Callers must hold the lock.
+func (s *MemoryCartStore) unindex(cart Cart) {
+	ids := s.byUsername[cart.Username]
+	delete(ids, cart.ID)
+	if len(ids) == 0 {
+		delete(s.byUsername, cart.Username)
+	}
+}
+
+// copyCart returns a cart that does not share its items or promotions with c,
+// so callers can't modify stored carts without going through the store.
+func copyCart(c Cart) Cart {
+	if c.Items != nil {
+		items := make(CartItems, len(c.Items))
+		copy(items, c.Items)
+		c.Items = items
+	}
+	if c.PromotionCodes != nil {
+		codes := make([]string, len(c.PromotionCodes))
+		copy(codes, c.PromotionCodes)
+		c.PromotionCodes = codes
+	}
+	if c.Promotions != nil {
+		promotions := make([]AppliedPromotion, len(c.Promotions))
+		copy(promotions, c.Promotions)
+		c.Promotions = promotions
+	}
+	if c.TaxLines != nil {
+		lines := make([]TaxLine, len(c.TaxLines))
+		copy(lines, c.TaxLines)
+		c.TaxLines = lines
+	}
+	if c.ShippingAddress != nil {
+		address := *c.ShippingAddress
+		c.ShippingAddress = &address
+	}
+	return c
+}
@@ -0,0 +1,146 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"shared/address"
+	"shared/services"
+)
+
+// Orders service location passed via environment (local development).
+// When not set, the orders service is discovered through Cloud Map.
+var ordersService = services.NewEndpoint("orders", os.Getenv("ORDER_SERVICE_HOST"), os.Getenv("ORDER_SERVICE_PORT"), sess)
+
+// Order Struct - the orders service representation of an order
+type Order struct {
+	ID               string             `json:"id"`
+	Username         string             `json:"username"`
+	CartID           string             `json:"cart_id"`
+	Items            OrderItems         `json:"items"`
+	Subtotal         float32            `json:"subtotal"`
+	Discount         float32            `json:"discount"`
+	Tax              float32            `json:"tax"`
+	Total            float32            `json:"total"`
+	PromotionCodes   []string           `json:"promotion_codes"`
+	FreeShipping     bool               `json:"free_shipping"`
+	Shipping         *ShippingSelection `json:"shipping,omitempty"`
+	BillingAddress   Address            `json:"billing_address"`
+	ShippingAddress  Address            `json:"shipping_address"`
+	CollectionPhone  string             `json:"collection_phone"`
+	DeliveryType     string             `json:"delivery_type"`
+	DeliveryStatus   string             `json:"delivery_status"`
+	DeliveryComplete bool               `json:"delivery_complete"`
+	Channel          string             `json:"channel"`
+	ChannelDetail    ChannelDetail      `json:"channel_detail"`
+}
+
+// ShippingSelection Struct - the shipping option chosen for an order, filled
+// in by the orders service
+type ShippingSelection struct {
+	OptionID                  string  `json:"option_id"`
+	Carrier                   string  `json:"carrier"`
+	Name                      string  `json:"name"`
+	Cost                      float32 `json:"cost"`
+	EstimatedDeliveryEarliest string  `json:"estimated_delivery_earliest"`
+	EstimatedDeliveryLatest   string  `json:"estimated_delivery_latest"`
+}
+
+// OrderItem Struct
+type OrderItem struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	Price       float32 `json:"price"`
+	Discount    float32 `json:"discount"`
+}
+
+// OrderItems Array
+type OrderItems []OrderItem
+
+// Address Struct
+type Address = address.Address
+
+// ChannelDetail Struct
+type ChannelDetail struct {
+	ChannelId int    `json:"channel_id"`
+	ChnnelGeo string `json:"channel_geo"`
+}
+
+// OrderRejectedError is returned when the orders service refuses an order
+// for a reason the client can act on, with the status and message it gave
+type OrderRejectedError struct {
+	Status  int
+	Message string
+}
+
+func (e *OrderRejectedError) Error() string {
+	return e.Message
+}
+
+// OrderService creates orders in the orders service
+type OrderService interface {
+	// CreateOrder creates the order. Requests with the same idempotencyKey
+	// create at most one order.
+	CreateOrder(order Order, idempotencyKey string) (Order, error)
+}
+
+var orderService OrderService = NewHTTPOrderService()
+
+// HTTPOrderService calls the orders service REST API
+type HTTPOrderService struct {
+	client   *http.Client
+	endpoint *services.Endpoint
+}
+
+// NewHTTPOrderService Function
+func NewHTTPOrderService() *HTTPOrderService {
+	return &HTTPOrderService{client: &http.Client{Timeout: 10 * time.Second}, endpoint: ordersService}
+}
+
+// CreateOrder Function
+// Stock shortages and validation errors are returned as the orders service
+// reported them, and other 4xx responses as an OrderRejectedError.
+func (s *HTTPOrderService) CreateOrder(order Order, idempotencyKey string) (Order, error) {
+	header := http.Header{}
+	header.Set("Idempotency-Key", idempotencyKey)
+	body, status, err := s.endpoint.Do(s.client, "POST", "/orders", order, header)
+	if err != nil {
+		return Order{}, err
+	}
+	if status == http.StatusConflict {
+		var stockErr OutOfStockError
+		if err := json.Unmarshal(body, &stockErr); err == nil && len(stockErr.Items) > 0 {
+			return Order{}, &stockErr
+		}
+	}
+	if status == http.StatusUnprocessableEntity {
+		var verr ValidationError
+		if err := json.Unmarshal(body, &verr); err == nil && len(verr.Errors) > 0 {
+			return Order{}, &verr
+		}
+	}
+	if status >= 400 && status < 500 {
+		return Order{}, &OrderRejectedError{Status: status, Message: strings.TrimSpace(string(body))}
+	}
+	if status != http.StatusOK && status != http.StatusCreated {
+		return Order{}, fmt.Errorf("orders service returned status %d", status)
+	}
+
+	var created Order
+	if err := json.Unmarshal(body, &created); err != nil {
+		return Order{}, err
+	}
+	if len(created.ID) == 0 {
+		return Order{}, fmt.Errorf("orders service did not return an order ID")
+	}
+
+	return created, nil
+}
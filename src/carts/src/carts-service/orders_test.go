@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"shared/services"
+)
+
+func TestHTTPOrderServiceCreateOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantErrType interface{}
+		wantStatus  int
+	}{
+		{name: "created", status: http.StatusCreated, body: `{"id":"order-1"}`},
+		{name: "out of stock", status: http.StatusConflict, body: `{"items":[{"product_id":"shirt","requested":2,"available":1}]}`, wantErrType: &OutOfStockError{}},
+		{name: "invalid order", status: http.StatusUnprocessableEntity, body: `{"errors":[{"field":"promotion_codes[0]","message":"has reached its usage limit"}]}`, wantErrType: &ValidationError{}},
+		{name: "rejected", status: http.StatusConflict, body: "Idempotency-Key is already in use\n", wantErrType: &OrderRejectedError{}, wantStatus: http.StatusConflict},
+		{name: "unavailable", status: http.StatusServiceUnavailable, body: "Unable to look up product prices\n", wantErrType: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				key = r.Header.Get("Idempotency-Key")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			u, _ := url.Parse(server.URL)
+
+			service := &HTTPOrderService{client: &http.Client{Timeout: time.Second}, endpoint: services.NewEndpoint("orders", u.Hostname(), u.Port(), nil)}
+			order, err := service.CreateOrder(Order{CartID: "cart-1"}, "cart-1-3")
+
+			if key != "cart-1-3" {
+				t.Errorf("Idempotency-Key = %q, want %q", key, "cart-1-3")
+			}
+			if tt.status == http.StatusCreated {
+				if err != nil || order.ID != "order-1" {
+					t.Errorf("CreateOrder() = %v, %v, want order-1", order.ID, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("CreateOrder() succeeded, want an error")
+			}
+			if tt.wantErrType != nil && reflect.TypeOf(err) != reflect.TypeOf(tt.wantErrType) {
+				t.Fatalf("CreateOrder() error = %T %v, want %T", err, err, tt.wantErrType)
+			}
+			if tt.wantErrType == nil {
+				switch err.(type) {
+				case *OutOfStockError, *ValidationError, *OrderRejectedError:
+					t.Errorf("CreateOrder() error = %T, want a service failure", err)
+				}
+			}
+			if rejected, ok := err.(*OrderRejectedError); ok && rejected.Status != tt.wantStatus {
+				t.Errorf("rejected status = %d, want %d", rejected.Status, tt.wantStatus)
+			}
+		})
+	}
+}
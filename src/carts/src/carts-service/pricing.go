@@ -0,0 +1,116 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"shared/address"
+	"shared/money"
+	"shared/tax"
+)
+
+// Currency of catalog prices. The products service does not carry a currency.
+var cartCurrency = getEnvDefault("CART_CURRENCY", "USD")
+
+// ErrCatalogUnavailable is returned when products can't be looked up
+var ErrCatalogUnavailable = errors.New("Unable to reach the products service")
+
+// ProductNotFoundError is returned when a cart references a product that is
+// not in the catalog
+type ProductNotFoundError struct {
+	ProductID string
+}
+
+func (e *ProductNotFoundError) Error() string {
+	return "Product not found: " + e.ProductID
+}
+
+// PriceCart resolves every item against the product catalog, overwriting the
+// client supplied name and price, and computes the cart totals. Items whose
+// catalog price differs from the price when they were added are flagged. The
+// cart's promotion codes are then applied to the priced items. Tax is
+// estimated on the discounted items when the cart has a shipping address.
+func PriceCart(cart *Cart, catalog ProductCatalog) error {
+	ids := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		ids = append(ids, item.ProductID)
+	}
+
+	products := map[string]CatalogProduct{}
+	if len(ids) > 0 {
+		var err error
+		if products, err = catalog.FindProducts(ids); err != nil {
+			log.Println("PriceCart error looking up products: ", err)
+			return ErrCatalogUnavailable
+		}
+	}
+
+	var subtotal float64
+	itemCount := 0
+
+	for i := range cart.Items {
+		item := &cart.Items[i]
+
+		product, ok := products[item.ProductID]
+		if !ok {
+			return &ProductNotFoundError{ProductID: item.ProductID}
+		}
+
+		item.ProductName = product.Name
+		item.Price = product.Price
+		if item.PriceWhenAdded == 0 {
+			item.PriceWhenAdded = product.Price
+		}
+		item.PriceChanged = item.Price != item.PriceWhenAdded
+		item.Discount = 0
+
+		subtotal += float64(item.Price) * float64(item.Quantity)
+		itemCount += item.Quantity
+	}
+
+	cart.Subtotal = money.Round(subtotal)
+	cart.ItemCount = itemCount
+	cart.Currency = cartCurrency
+
+	applyPromotions(cart, products, promotionStore, time.Now())
+
+	var discount float64
+	for _, item := range cart.Items {
+		discount += float64(item.Discount)
+	}
+	cart.Discount = money.Round(discount)
+
+	cart.Tax = 0
+	cart.TaxLines = []TaxLine{}
+	if cart.ShippingAddress != nil {
+		// Normalized the way the orders service normalizes order addresses
+		normalized := address.Normalize(*cart.ShippingAddress)
+		cart.ShippingAddress = &normalized
+
+		taxable := make([]tax.Item, len(cart.Items))
+		for i, item := range cart.Items {
+			amount := float64(item.Price)*float64(item.Quantity) - float64(item.Discount)
+			taxable[i] = tax.Item{Category: products[item.ProductID].Category, Amount: amount}
+		}
+		taxed := taxCalculator.Calculate(*cart.ShippingAddress, taxable)
+		cart.Tax = taxed.Total
+		cart.TaxLines = taxed.Lines
+	}
+
+	cart.Total = money.Round(float64(cart.Subtotal) - float64(cart.Discount) + float64(cart.Tax))
+
+	return nil
+}
+
+// getEnvDefault returns the environment variable or fallback when not set
+func getEnvDefault(key string, fallback string) string {
+	if value, exists := os.LookupEnv(key); exists && len(value) > 0 {
+		return value
+	}
+	return fallback
+}
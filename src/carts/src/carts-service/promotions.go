@@ -0,0 +1,156 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"shared/promotions"
+)
+
+// Errors returned when a promotion code can't be applied
+var (
+	ErrMissingPromotionCode   = errors.New("Promotion code is required")
+	ErrPromotionNotFound      = promotions.ErrNotFound
+	ErrPromotionNotActive     = promotions.ErrNotActive
+	ErrPromotionUsedUp        = promotions.ErrUsedUp
+	ErrPromotionNotApplicable = errors.New("Promotion code does not apply to any item in the cart")
+	ErrPromotionNotInCart     = errors.New("Promotion code is not applied to the cart")
+)
+
+// Promotion Struct - a coupon code and the discount it gives
+type Promotion = promotions.Promotion
+
+// AppliedPromotion Struct - a promotion applied to a priced cart
+type AppliedPromotion = promotions.Applied
+
+// PromotionCode Struct - request body for applying a promotion to a cart
+type PromotionCode struct {
+	Code string `json:"code" yaml:"code"`
+}
+
+// NormalizePromotionCode returns code in the form promotions are stored under
+func NormalizePromotionCode(code string) string {
+	return promotions.NormalizeCode(code)
+}
+
+// DynamoDB table name for promotion usage counts, shared with the orders
+// service. When empty, uses are counted in memory.
+var ddbTablePromotionUsage = os.Getenv("DDB_TABLE_PROMOTION_USAGE")
+
+// PromotionStore holds promotions. Uses are counted by the orders service when
+// an order is created; carts read the counts so used up codes aren't applied.
+type PromotionStore interface {
+	// FindByCode returns the promotion for code, with its uses counted so
+	// far, or ErrPromotionNotFound
+	FindByCode(code string) (Promotion, error)
+}
+
+var promotionStore PromotionStore
+
+// Init
+func init() {
+	promotionStore = NewMemoryPromotionStore(promotions.Configured(os.Getenv("PROMOTIONS_FILE")), NewPromotionUsageStore())
+}
+
+// NewPromotionUsageStore returns a DynamoDB backed usage store when a
+// promotion usage table is configured, otherwise an in-memory store.
+func NewPromotionUsageStore() promotions.UsageStore {
+	if len(ddbTablePromotionUsage) > 0 {
+		log.Println("Using DynamoDB promotion usage store with table: ", ddbTablePromotionUsage)
+		return promotions.NewDynamoUsageStore(dynamoClient, ddbTablePromotionUsage)
+	}
+
+	log.Println("Using in-memory promotion usage store")
+	return promotions.NewMemoryUsageStore()
+}
+
+// MemoryPromotionStore keeps the configured promotions in process memory and
+// reads their uses from a usage store
+type MemoryPromotionStore struct {
+	promotions map[string]Promotion
+	usage      promotions.UsageStore
+}
+
+// NewMemoryPromotionStore Function
+func NewMemoryPromotionStore(values []Promotion, usage promotions.UsageStore) *MemoryPromotionStore {
+	s := &MemoryPromotionStore{promotions: make(map[string]Promotion, len(values)), usage: usage}
+	for _, p := range values {
+		p.Code = NormalizePromotionCode(p.Code)
+		s.promotions[p.Code] = p
+	}
+	return s
+}
+
+// FindByCode Function
+func (s *MemoryPromotionStore) FindByCode(code string) (Promotion, error) {
+	p, ok := s.promotions[NormalizePromotionCode(code)]
+	if !ok {
+		return Promotion{}, ErrPromotionNotFound
+	}
+
+	found := []Promotion{p}
+	if err := promotions.CountUses(s.usage, found); err != nil {
+		return Promotion{}, err
+	}
+	return found[0], nil
+}
+
+// applyPromotions discounts the priced lines of cart with each of its
+// promotion codes in turn. Codes that no longer exist or have expired are
+// removed from the cart; codes that match no items stay on the cart with no
+// discount.
+func applyPromotions(cart *Cart, products map[string]CatalogProduct, store PromotionStore, now time.Time) {
+	active := make([]Promotion, 0, len(cart.PromotionCodes))
+	codes := make([]string, 0, len(cart.PromotionCodes))
+	for _, code := range cart.PromotionCodes {
+		promotion, err := store.FindByCode(code)
+		if err == nil {
+			err = promotion.Active(now)
+		}
+		switch err {
+		case nil:
+		case ErrPromotionNotFound, ErrPromotionNotActive, ErrPromotionUsedUp:
+			log.Println("Removing promotion from cart: ", cart.ID, code, err)
+			continue
+		default:
+			// Keep the code for when its uses can be counted again
+			log.Println("Unable to apply promotion to cart: ", cart.ID, code, err)
+			codes = append(codes, code)
+			continue
+		}
+		active = append(active, promotion)
+		codes = append(codes, promotion.Code)
+	}
+	cart.PromotionCodes = codes
+
+	lines := make([]promotions.Line, len(cart.Items))
+	for i, item := range cart.Items {
+		lines[i] = promotions.Line{
+			ProductID: item.ProductID,
+			Category:  products[item.ProductID].Category,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+			Discount:  item.Discount,
+		}
+	}
+
+	cart.Promotions, cart.FreeShipping = promotions.Apply(lines, active)
+	for i := range cart.Items {
+		cart.Items[i].Discount = lines[i].Discount
+	}
+}
+
+// promotionApplies reports whether the promotion targets any item in the cart
+func promotionApplies(promotion Promotion, items CartItems, products map[string]CatalogProduct) bool {
+	for _, item := range items {
+		if promotion.AppliesTo(item.ProductID, products[item.ProductID].Category) {
+			return true
+		}
+	}
+	return false
+}
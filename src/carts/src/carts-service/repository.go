@@ -4,42 +4,285 @@
 package main
 
 import (
-	"strconv"
+	"log"
+	"time"
 )
 
-var currentID int
+var cartStore CartStore
 
-var carts = map[string]Cart{}
+// Init
+func init() {
+	// Changes are published so clients following a cart see them
+	cartStore = NewBroadcastingCartStore(NewCartStore(), cartBroker)
+}
 
+// RepoFindAllCarts Function
+func RepoFindAllCarts() []Cart {
+	values, err := cartStore.FindAll()
+	if err != nil {
+		log.Println("RepoFindAllCarts error: ", err)
+		return []Cart{}
+	}
+	return values
+}
 
 // RepoFindCartByID Function
 func RepoFindCartByID(id string) Cart {
-	cart, ok := carts[id]
-	if !ok {
+	cart, err := cartStore.FindByID(id)
+	if err != nil {
+		if err != ErrCartNotFound {
+			log.Println("RepoFindCartByID error: ", err)
+		}
 		return Cart{}
 	}
 	return cart
 }
 
+// RepoFindCartsByUsername Function
+func RepoFindCartsByUsername(username string) []Cart {
+	values, err := cartStore.FindByUsername(username)
+	if err != nil {
+		log.Println("RepoFindCartsByUsername error: ", err)
+		return []Cart{}
+	}
+	return values
+}
+
+// maxUpdateAttempts bounds retries of unconditional updates that lose a race
+// with another writer
+const maxUpdateAttempts = 5
+
 // RepoUpdateCart Function
-func RepoUpdateCart(id string, cart Cart) Cart {
-	_, ok := carts[id]
+// When expectedVersion is non-zero the update only succeeds if the stored cart
+// is still at that version. Otherwise the cart is replaced regardless of its
+// current version.
+func RepoUpdateCart(id string, cart Cart, expectedVersion int) (Cart, error) {
+	return RepoMutateCart(id, expectedVersion, func(existing *Cart) error {
+		// Keep the price each product was added at rather than trusting the client
+		pricesWhenAdded := make(map[string]float32, len(existing.Items))
+		for _, item := range existing.Items {
+			pricesWhenAdded[item.ProductID] = item.PriceWhenAdded
+		}
 
-	if !ok {
-		// return empty Cart if not found
-		return Cart{}
-	}
+		items := cart.Items.Merged()
+		for i := range items {
+			items[i].PriceWhenAdded = pricesWhenAdded[items[i].ProductID]
+		}
+
+		existing.Username = cart.Username
+		existing.Items = items
+		existing.ShippingAddress = cart.ShippingAddress
+		return nil
+	})
+}
 
-	cart.ID = id
-	carts[id] = cart
+// RepoMutateCart Function
+// Loads the cart, applies mutate to it, reprices it and stores the result. When
+// expectedVersion is zero, a write that loses a race with another writer is
+// retried against the newer cart; otherwise ErrCartVersionConflict is returned.
+// An error returned by mutate aborts the update and is passed through. Every
+// change counts as cart activity and is recorded in UpdatedAt. Stock is
+// reserved for changed quantities before the cart is stored.
+func RepoMutateCart(id string, expectedVersion int, mutate func(*Cart) error) (Cart, error) {
+	for attempt := 0; ; attempt++ {
+		cart, err := cartStore.FindByID(id)
+		if err != nil {
+			if err != ErrCartNotFound {
+				log.Println("RepoMutateCart error: ", err)
+			}
+			return Cart{}, err
+		}
 
-	return cart
+		if expectedVersion > 0 && cart.Version != expectedVersion {
+			return Cart{}, ErrCartVersionConflict
+		}
+
+		reserved := itemQuantities(cart.Items)
+		if err := mutate(&cart); err != nil {
+			return Cart{}, err
+		}
+		cart.ID = id
+		cart.UpdatedAt = time.Now().UTC()
+		cart.AbandonedNotified = false
+
+		if err := PriceCart(&cart, productCatalog); err != nil {
+			return Cart{}, err
+		}
+
+		quantities := itemQuantities(cart.Items)
+		if err := updateReservations(id, reserved, quantities); err != nil {
+			return Cart{}, err
+		}
+
+		updated, err := cartStore.Update(cart)
+		if err != nil {
+			if err := updateReservations(id, quantities, reserved); err != nil {
+				log.Println("RepoMutateCart unable to restore reservations: ", id, err)
+			}
+		}
+		if err != ErrCartVersionConflict || expectedVersion > 0 || attempt+1 >= maxUpdateAttempts {
+			if err != nil && err != ErrCartNotFound && err != ErrCartVersionConflict {
+				log.Println("RepoMutateCart error: ", err)
+			}
+			return updated, err
+		}
+	}
 }
 
 // RepoCreateCart Function
-func RepoCreateCart(t Cart) Cart {
-	currentID++
-	t.ID = strconv.Itoa(currentID)
-	carts[t.ID] = t
-	return t
+func RepoCreateCart(t Cart) (Cart, error) {
+	t.Items = t.Items.Merged()
+	for i := range t.Items {
+		t.Items[i].PriceWhenAdded = 0
+	}
+	// Promotions are applied through RepoApplyPromotion so they are validated
+	t.PromotionCodes = nil
+	t.CreatedAt = time.Now().UTC()
+	t.UpdatedAt = t.CreatedAt
+	t.AbandonedNotified = false
+
+	if err := PriceCart(&t, productCatalog); err != nil {
+		return Cart{}, err
+	}
+
+	created, err := cartStore.Create(t)
+	if err != nil {
+		log.Println("RepoCreateCart error: ", err)
+		return Cart{}, err
+	}
+
+	// Stock is reserved under the cart ID, so the cart has to exist first
+	if err := updateReservations(created.ID, nil, itemQuantities(created.Items)); err != nil {
+		if err := cartStore.Delete(created.ID); err != nil {
+			log.Println("RepoCreateCart unable to delete cart after failed reservation: ", created.ID, err)
+		}
+		return Cart{}, err
+	}
+	return created, nil
+}
+
+// RepoMergeCarts Function
+// Moves the items of the source cart into the target cart, summing quantities
+// of products in both, and deletes the source cart. The target cart takes the
+// source cart's username if it doesn't have one. The source cart is emptied
+// before the target is changed, which releases its stock reservations so the
+// stock can move to the target. It is then only deleted at the emptied
+// version, so items added to it during the merge stay in it.
+func RepoMergeCarts(targetID string, sourceID string, expectedVersion int) (Cart, error) {
+	if targetID == sourceID {
+		return Cart{}, ErrMergeSameCart
+	}
+
+	var source Cart
+	emptied, err := RepoMutateCart(sourceID, 0, func(cart *Cart) error {
+		source = copyCart(*cart)
+		cart.Items = CartItems{}
+		cart.PromotionCodes = nil
+		return nil
+	})
+	if err != nil {
+		return Cart{}, err
+	}
+
+	merged, err := RepoMutateCart(targetID, expectedVersion, func(target *Cart) error {
+		if len(target.Username) == 0 {
+			target.Username = source.Username
+		}
+		target.Items = append(target.Items, source.Items...).Merged()
+		target.PromotionCodes = uniqueStrings(append(target.PromotionCodes, source.PromotionCodes...))
+		return nil
+	})
+	if err != nil {
+		_, restoreErr := RepoMutateCart(sourceID, 0, func(cart *Cart) error {
+			cart.Items = append(cart.Items, source.Items...).Merged()
+			cart.PromotionCodes = uniqueStrings(append(cart.PromotionCodes, source.PromotionCodes...))
+			return nil
+		})
+		if restoreErr != nil {
+			log.Println("RepoMergeCarts unable to restore source cart: ", sourceID, restoreErr)
+		}
+		return Cart{}, err
+	}
+
+	err = cartStore.DeleteVersion(sourceID, emptied.Version)
+	if err != nil && err != ErrCartNotFound && err != ErrCartVersionConflict {
+		log.Println("RepoMergeCarts unable to delete merged cart: ", sourceID, err)
+		return Cart{}, err
+	}
+
+	return merged, nil
+}
+
+// RepoApplyPromotion Function
+// Adds a promotion code to the cart after checking that it exists, is active
+// and applies to at least one item in the cart. Applying a code the cart
+// already has leaves the cart unchanged.
+func RepoApplyPromotion(id string, code string, expectedVersion int) (Cart, error) {
+	code = NormalizePromotionCode(code)
+	if len(code) == 0 {
+		return Cart{}, ErrMissingPromotionCode
+	}
+
+	promotion, err := promotionStore.FindByCode(code)
+	if err != nil {
+		return Cart{}, err
+	}
+	if err := promotion.Active(time.Now()); err != nil {
+		return Cart{}, err
+	}
+
+	return RepoMutateCart(id, expectedVersion, func(cart *Cart) error {
+		for _, existing := range cart.PromotionCodes {
+			if existing == promotion.Code {
+				return nil
+			}
+		}
+
+		ids := make([]string, 0, len(cart.Items))
+		for _, item := range cart.Items {
+			ids = append(ids, item.ProductID)
+		}
+		products := map[string]CatalogProduct{}
+		if len(ids) > 0 {
+			var err error
+			if products, err = productCatalog.FindProducts(ids); err != nil {
+				log.Println("RepoApplyPromotion error looking up products: ", err)
+				return ErrCatalogUnavailable
+			}
+		}
+		if !promotionApplies(promotion, cart.Items, products) {
+			return ErrPromotionNotApplicable
+		}
+
+		cart.PromotionCodes = append(cart.PromotionCodes, promotion.Code)
+		return nil
+	})
+}
+
+// RepoRemovePromotion Function
+func RepoRemovePromotion(id string, code string, expectedVersion int) (Cart, error) {
+	code = NormalizePromotionCode(code)
+
+	return RepoMutateCart(id, expectedVersion, func(cart *Cart) error {
+		for i, existing := range cart.PromotionCodes {
+			if existing == code {
+				cart.PromotionCodes = append(cart.PromotionCodes[:i], cart.PromotionCodes[i+1:]...)
+				return nil
+			}
+		}
+		return ErrPromotionNotInCart
+	})
+}
+
+// uniqueStrings returns values without duplicates, preserving order
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
 }
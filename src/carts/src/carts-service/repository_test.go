@@ -0,0 +1,104 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeCarts(t *testing.T) {
+	tests := []struct {
+		name            string
+		expectedVersion int
+		addToSource     bool
+		wantErr         error
+		wantTarget      map[string]int
+		wantSource      map[string]int
+		wantReserved    map[string]int
+	}{
+		{
+			name:         "merge",
+			wantTarget:   map[string]int{"shirt": 3, "apple": 1},
+			wantReserved: map[string]int{"source/shirt": 0, "target/shirt": 3},
+		},
+		{
+			name:         "items added to the source during the merge",
+			addToSource:  true,
+			wantTarget:   map[string]int{"shirt": 3, "apple": 1},
+			wantSource:   map[string]int{"apple": 5},
+			wantReserved: map[string]int{"source/shirt": 0, "target/shirt": 3},
+		},
+		{
+			name:            "target changed",
+			expectedVersion: 7,
+			wantErr:         ErrCartVersionConflict,
+			wantTarget:      map[string]int{"shirt": 2, "apple": 1},
+			wantSource:      map[string]int{"shirt": 1},
+			wantReserved:    map[string]int{"source/shirt": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := &fakeCatalog{
+				products: map[string]CatalogProduct{
+					"shirt": {ID: "shirt", Name: "Shirt", Price: 20},
+					"apple": {ID: "apple", Name: "Apple", Price: 1.25},
+				},
+				reserved: map[string]int{},
+			}
+			savedCatalog, savedStore := productCatalog, cartStore
+			productCatalog = catalog
+			memory := NewMemoryCartStore()
+			cartStore = memory
+			defer func() { productCatalog, cartStore = savedCatalog, savedStore }()
+
+			target, _ := memory.Create(Cart{Items: CartItems{{ProductID: "shirt", Quantity: 2}, {ProductID: "apple", Quantity: 1}}})
+			source, _ := memory.Create(Cart{Username: "ada", Items: CartItems{{ProductID: "shirt", Quantity: 1}}})
+			catalog.onReserve = func(productID string, reservationID string) {
+				if reservationID != target.ID || !tt.addToSource {
+					return
+				}
+				// A shopper adds to the source cart while the target is reserved
+				added, _ := memory.FindByID(source.ID)
+				added.Items = append(added.Items, CartItem{ProductID: "apple", Quantity: 5})
+				memory.Update(added)
+				tt.addToSource = false
+			}
+
+			merged, err := RepoMergeCarts(target.ID, source.ID, tt.expectedVersion)
+			if err != tt.wantErr {
+				t.Fatalf("RepoMergeCarts() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && merged.Username != "ada" {
+				t.Errorf("merged username = %q, want the source's", merged.Username)
+			}
+
+			stored, _ := memory.FindByID(target.ID)
+			if got := itemQuantities(stored.Items); !reflect.DeepEqual(got, tt.wantTarget) {
+				t.Errorf("target items = %v, want %v", got, tt.wantTarget)
+			}
+			kept, err := memory.FindByID(source.ID)
+			if tt.wantSource == nil {
+				if err != ErrCartNotFound {
+					t.Errorf("source cart kept with %v", kept.Items)
+				}
+			} else if got := itemQuantities(kept.Items); !reflect.DeepEqual(got, tt.wantSource) {
+				t.Errorf("source items = %v, want %v", got, tt.wantSource)
+			}
+
+			// Reservations are held under cart IDs
+			ids := strings.NewReplacer("target", target.ID, "source", source.ID)
+			wantReserved := map[string]int{}
+			for key, quantity := range tt.wantReserved {
+				wantReserved[ids.Replace(key)] = quantity
+			}
+			if !reflect.DeepEqual(catalog.reserved, wantReserved) {
+				t.Errorf("reservations set = %v, want %v", catalog.reserved, wantReserved)
+			}
+		})
+	}
+}
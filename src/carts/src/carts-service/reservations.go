@@ -0,0 +1,78 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// How long stock stays reserved for a cart after its quantity last changed.
+// Reservations that expire are released by the products service.
+var cartReservationTTL = time.Duration(getEnvInt("CART_RESERVATION_MINUTES", 60)) * time.Minute
+
+// itemQuantities returns the total quantity of each product in items
+func itemQuantities(items CartItems) map[string]int {
+	quantities := make(map[string]int, len(items))
+	for _, item := range items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities
+}
+
+// updateReservations changes the stock reserved for a cart from the
+// quantities in from to those in to. Only products whose quantity differs are
+// touched. If any reservation fails, the ones already changed are put back
+// and the error is returned.
+func updateReservations(cartID string, from map[string]int, to map[string]int) error {
+	changed := []string{}
+	for productID, quantity := range to {
+		if from[productID] != quantity {
+			changed = append(changed, productID)
+		}
+	}
+	for productID := range from {
+		if _, ok := to[productID]; !ok {
+			changed = append(changed, productID)
+		}
+	}
+	sort.Strings(changed)
+
+	for i, productID := range changed {
+		if err := reserve(productID, cartID, to[productID]); err != nil {
+			for _, applied := range changed[:i] {
+				if err := reserve(applied, cartID, from[applied]); err != nil {
+					log.Println("updateReservations unable to restore reservation: ", cartID, applied, err)
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releaseReservations releases all stock reserved for a cart. Failures are only
+// logged since unreleased reservations expire on their own.
+func releaseReservations(cartID string, items CartItems) {
+	for productID := range itemQuantities(items) {
+		if err := reserve(productID, cartID, 0); err != nil {
+			log.Println("releaseReservations unable to release reservation: ", cartID, productID, err)
+		}
+	}
+}
+
+// reserve sets the quantity reserved for a cart, mapping failures to reach the
+// products service to ErrCatalogUnavailable
+func reserve(productID string, cartID string, quantity int) error {
+	err := productCatalog.Reserve(productID, cartID, quantity, cartReservationTTL)
+	switch err.(type) {
+	case nil, *OutOfStockError, *ProductNotFoundError:
+		return err
+	}
+
+	log.Println("reserve error: ", productID, cartID, err)
+	return ErrCatalogUnavailable
+}
@@ -35,6 +35,18 @@ var routes = Routes{
 		"/carts/{cartID}",
 		CartShowByID,
 	},
+	Route{
+		"CartEvents",
+		"GET",
+		"/carts/{cartID}/events",
+		CartEvents,
+	},
+	Route{
+		"CartShowByUsername",
+		"GET",
+		"/carts/username/{username}",
+		CartIndexByUsername,
+	},
 	Route{
 		"CartCreate",
 		"POST",
@@ -59,6 +71,198 @@ var routes = Routes{
 		"/carts/{cartID}",
 		CartUpdate,
 	},
+	Route{
+		"CartItemAdd",
+		"POST",
+		"/carts/{cartID}/items",
+		CartItemAdd,
+	},
+	Route{
+		"CartItemAdd",
+		"OPTIONS",
+		"/carts/{cartID}/items",
+		CartItemAdd,
+	},
+	Route{
+		"CartItemUpdate",
+		"PATCH",
+		"/carts/{cartID}/items/{productID}",
+		CartItemUpdate,
+	},
+	Route{
+		"CartItemDelete",
+		"DELETE",
+		"/carts/{cartID}/items/{productID}",
+		CartItemDelete,
+	},
+	Route{
+		"CartItemUpdate",
+		"OPTIONS",
+		"/carts/{cartID}/items/{productID}",
+		CartItemUpdate,
+	},
+	Route{
+		"CartItemMoveToList",
+		"POST",
+		"/carts/{cartID}/items/{productID}/move",
+		CartItemMoveToList,
+	},
+	Route{
+		"CartItemMoveToList",
+		"OPTIONS",
+		"/carts/{cartID}/items/{productID}/move",
+		CartItemMoveToList,
+	},
+	Route{
+		"CartPromotionAdd",
+		"POST",
+		"/carts/{cartID}/promotions",
+		CartPromotionAdd,
+	},
+	Route{
+		"CartPromotionAdd",
+		"OPTIONS",
+		"/carts/{cartID}/promotions",
+		CartPromotionAdd,
+	},
+	Route{
+		"CartPromotionDelete",
+		"DELETE",
+		"/carts/{cartID}/promotions/{code}",
+		CartPromotionDelete,
+	},
+	Route{
+		"CartPromotionDelete",
+		"OPTIONS",
+		"/carts/{cartID}/promotions/{code}",
+		CartPromotionDelete,
+	},
+	Route{
+		"CartMerge",
+		"POST",
+		"/carts/{cartID}/merge/{otherID}",
+		CartMerge,
+	},
+	Route{
+		"CartMerge",
+		"OPTIONS",
+		"/carts/{cartID}/merge/{otherID}",
+		CartMerge,
+	},
+	Route{
+		"CartCheckout",
+		"POST",
+		"/carts/{cartID}/checkout",
+		CartCheckout,
+	},
+	Route{
+		"CartCheckout",
+		"OPTIONS",
+		"/carts/{cartID}/checkout",
+		CartCheckout,
+	},
+	Route{
+		"ListCreate",
+		"POST",
+		"/lists",
+		ListCreate,
+	},
+	Route{
+		"ListCreate",
+		"OPTIONS",
+		"/lists",
+		ListCreate,
+	},
+	Route{
+		"ListIndexByUsername",
+		"GET",
+		"/lists/username/{username}",
+		ListIndexByUsername,
+	},
+	Route{
+		"SharedListShow",
+		"GET",
+		"/lists/shared/{token}",
+		SharedListShow,
+	},
+	Route{
+		"ListShowByID",
+		"GET",
+		"/lists/{listID}",
+		ListShowByID,
+	},
+	Route{
+		"ListUpdate",
+		"PUT",
+		"/lists/{listID}",
+		ListUpdate,
+	},
+	Route{
+		"ListDelete",
+		"DELETE",
+		"/lists/{listID}",
+		ListDelete,
+	},
+	Route{
+		"ListUpdate",
+		"OPTIONS",
+		"/lists/{listID}",
+		ListUpdate,
+	},
+	Route{
+		"ListItemAdd",
+		"POST",
+		"/lists/{listID}/items",
+		ListItemAdd,
+	},
+	Route{
+		"ListItemAdd",
+		"OPTIONS",
+		"/lists/{listID}/items",
+		ListItemAdd,
+	},
+	Route{
+		"ListItemDelete",
+		"DELETE",
+		"/lists/{listID}/items/{productID}",
+		ListItemDelete,
+	},
+	Route{
+		"ListItemDelete",
+		"OPTIONS",
+		"/lists/{listID}/items/{productID}",
+		ListItemDelete,
+	},
+	Route{
+		"ListItemMoveToCart",
+		"POST",
+		"/lists/{listID}/items/{productID}/move",
+		ListItemMoveToCart,
+	},
+	Route{
+		"ListItemMoveToCart",
+		"OPTIONS",
+		"/lists/{listID}/items/{productID}/move",
+		ListItemMoveToCart,
+	},
+	Route{
+		"ListShare",
+		"POST",
+		"/lists/{listID}/share",
+		ListShare,
+	},
+	Route{
+		"ListUnshare",
+		"DELETE",
+		"/lists/{listID}/share",
+		ListUnshare,
+	},
+	Route{
+		"ListShare",
+		"OPTIONS",
+		"/lists/{listID}/share",
+		ListShare,
+	},
 	Route{
 		"SignPayload",
 		"POST",
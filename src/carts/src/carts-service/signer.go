@@ -0,0 +1,211 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+)
+
+// ErrSignerNotConfigured is returned when no payload signer could be set up
+var ErrSignerNotConfigured = errors.New("Payload signing is not configured")
+
+// ErrSignerUnavailable is returned when the signing backend could not be reached
+var ErrSignerUnavailable = errors.New("Unable to sign payload")
+
+// PayloadSigner signs checkout payloads, such as the Amazon Pay checkout
+// session config, with the merchant's key
+type PayloadSigner interface {
+	Sign(payload []byte) (string, error)
+}
+
+var payloadSigner PayloadSigner
+
+// Init
+func init() {
+	signer, err := NewPayloadSigner()
+	if err != nil {
+		log.Println("Unable to configure payload signer; /sign will be unavailable: ", err)
+		return
+	}
+	payloadSigner = signer
+}
+
+// NewPayloadSigner returns the signer selected by PAYLOAD_SIGNER:
+// "lambda" (default) invokes AMAZON_PAY_SIGNING_LAMBDA, while "rsa" and "hmac"
+// sign locally with the key in PAYLOAD_SIGNING_KEY or PAYLOAD_SIGNING_KEY_FILE.
+func NewPayloadSigner() (PayloadSigner, error) {
+	signerType := strings.ToLower(getEnvDefault("PAYLOAD_SIGNER", "lambda"))
+
+	switch signerType {
+	case "lambda":
+		awsSession, err := session.NewSessionWithOptions(session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return nil, err
+		}
+		functionName := getEnvDefault("AMAZON_PAY_SIGNING_LAMBDA", "AmazonPaySigningLambda")
+		log.Println("Signing payloads with Lambda function: ", functionName)
+		return NewLambdaPayloadSigner(lambda.New(awsSession), functionName), nil
+	case "rsa", "hmac":
+		key, err := loadSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Signing payloads locally with key type: ", signerType)
+		if signerType == "rsa" {
+			signer, err := NewRSAPayloadSigner(key)
+			if err != nil {
+				return nil, err
+			}
+			return signer, nil
+		}
+		signer, err := NewHMACPayloadSigner(key)
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	}
+
+	return nil, fmt.Errorf("unknown PAYLOAD_SIGNER %q", signerType)
+}
+
+// loadSigningKey reads the local signing key from the environment or a file
+func loadSigningKey() ([]byte, error) {
+	if path := os.Getenv("PAYLOAD_SIGNING_KEY_FILE"); len(path) > 0 {
+		return ioutil.ReadFile(path)
+	}
+	if key := os.Getenv("PAYLOAD_SIGNING_KEY"); len(key) > 0 {
+		// Multi-line PEM keys are often passed with escaped new lines
+		return []byte(strings.Replace(key, `\n`, "\n", -1)), nil
+	}
+	return nil, errors.New("PAYLOAD_SIGNING_KEY or PAYLOAD_SIGNING_KEY_FILE must be set")
+}
+
+// LambdaPayloadSigner delegates signing to a Lambda function that holds the
+// merchant's private key
+type LambdaPayloadSigner struct {
+	client       *lambda.Lambda
+	functionName string
+}
+
+// NewLambdaPayloadSigner Function
+func NewLambdaPayloadSigner(client *lambda.Lambda, functionName string) *LambdaPayloadSigner {
+	return &LambdaPayloadSigner{client: client, functionName: functionName}
+}
+
+// lambdaSignResponse is the response returned by the signing Lambda function
+type lambdaSignResponse struct {
+	StatusCode int `json:"statusCode"`
+	Body       struct {
+		Signature string `json:"Signature"`
+	} `json:"body"`
+}
+
+// Sign Function
+func (s *LambdaPayloadSigner) Sign(payload []byte) (string, error) {
+	result, err := s.client.Invoke(&lambda.InvokeInput{FunctionName: aws.String(s.functionName), Payload: payload})
+	if err != nil {
+		log.Println("Error invoking signing function: ", err)
+		return "", ErrSignerUnavailable
+	}
+	if result.FunctionError != nil {
+		log.Println("Signing function failed: ", aws.StringValue(result.FunctionError), string(result.Payload))
+		return "", ErrSignerUnavailable
+	}
+
+	var response lambdaSignResponse
+	if err := json.Unmarshal(result.Payload, &response); err != nil {
+		log.Println("Unable to parse signing function response: ", err)
+		return "", ErrSignerUnavailable
+	}
+	if len(response.Body.Signature) == 0 {
+		log.Println("Signing function returned no signature; status: ", response.StatusCode)
+		return "", ErrSignerUnavailable
+	}
+
+	return response.Body.Signature, nil
+}
+
+// RSAPayloadSigner signs payloads the same way as Amazon Pay button signatures
+// (AMZN-PAY-RSASSA-PSS), so a real merchant key produces signatures Amazon Pay
+// accepts.
+type RSAPayloadSigner struct {
+	key *rsa.PrivateKey
+}
+
+// NewRSAPayloadSigner parses a PEM encoded PKCS#8 or PKCS#1 RSA private key
+func NewRSAPayloadSigner(pemKey []byte) (*RSAPayloadSigner, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return &RSAPayloadSigner{key: key}, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA private key")
+	}
+	return &RSAPayloadSigner{key: key}, nil
+}
+
+// Sign Function
+func (s *RSAPayloadSigner) Sign(payload []byte) (string, error) {
+	payloadHash := sha256.Sum256(payload)
+	stringToSign := "AMZN-PAY-RSASSA-PSS\n" + hex.EncodeToString(payloadHash[:])
+	hashed := sha256.Sum256([]byte(stringToSign))
+
+	signature, err := rsa.SignPSS(rand.Reader, s.key, crypto.SHA256, hashed[:], &rsa.PSSOptions{SaltLength: 20})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// HMACPayloadSigner signs payloads with HMAC-SHA256 and a shared secret. It
+// is meant for local development where no merchant key is available.
+type HMACPayloadSigner struct {
+	secret []byte
+}
+
+// NewHMACPayloadSigner Function
+func NewHMACPayloadSigner(secret []byte) (*HMACPayloadSigner, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("HMAC signing secret is empty")
+	}
+	return &HMACPayloadSigner{secret: secret}, nil
+}
+
+// Sign Function
+func (s *HMACPayloadSigner) Sign(payload []byte) (string, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,56 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+)
+
+// ErrCartNotFound is returned by a CartStore when no cart exists for an ID
+var ErrCartNotFound = errors.New("Cart not found")
+
+// ErrCartVersionConflict is returned by a CartStore when an update is based on
+// a version of the cart that is no longer current
+var ErrCartVersionConflict = errors.New("Cart has been modified since it was read")
+
+// CartStore persists carts. The repository functions delegate to the store
+// selected at startup so handlers don't need to know which backend is in use.
+// Implementations must be safe for concurrent use and stamp every write with
+// a new Version.
+type CartStore interface {
+	// FindAll returns every cart in the store
+	FindAll() ([]Cart, error)
+	// FindByID returns the cart for id or ErrCartNotFound
+	FindByID(id string) (Cart, error)
+	// FindByUsername returns the carts belonging to username
+	FindByUsername(username string) ([]Cart, error)
+	// Create assigns a new ID and the first version to cart and persists it
+	Create(cart Cart) (Cart, error)
+	// Update replaces an existing cart if cart.Version is still the stored
+	// version, otherwise it returns ErrCartVersionConflict
+	Update(cart Cart) (Cart, error)
+	// Delete removes the cart for id or returns ErrCartNotFound
+	Delete(id string) error
+	// DeleteVersion removes the cart for id if version is still the stored
+	// version, otherwise it returns ErrCartVersionConflict or ErrCartNotFound
+	DeleteVersion(id string, version int) error
+	// MarkAbandoned sets AbandonedNotified on the cart for id if version is
+	// still the stored version, otherwise it returns ErrCartVersionConflict or
+	// ErrCartNotFound. The version is left as it is, since clients can't see
+	// the mark.
+	MarkAbandoned(id string, version int) error
+}
+
+// NewCartStore returns a DynamoDB backed store when a carts table is
+// configured, otherwise an in-memory store.
+func NewCartStore() CartStore {
+	if len(ddbTableCarts) > 0 {
+		log.Println("Using DynamoDB cart store with table: ", ddbTableCarts)
+		return NewDynamoCartStore(dynamoClient, ddbTableCarts)
+	}
+
+	log.Println("Using in-memory cart store")
+	return NewMemoryCartStore()
+}
@@ -0,0 +1,15 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import "shared/tax"
+
+// Rules file location passed via environment. The file is reloaded when it
+// changes, so rates can be changed without a restart.
+var taxRulesFile = getEnvDefault("TAX_RULES_FILE", "tax-rules.json")
+
+var taxCalculator = tax.NewCalculator(taxRulesFile)
+
+// TaxLine Struct - the tax charged by one rule
+type TaxLine = tax.Line
@@ -8,4 +8,7 @@ require (
 	github.com/gorilla/mux v1.8.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
+	shared v0.0.0
 )
+
+replace shared => ../../../shared
@@ -30,12 +30,7 @@ func CartIndex(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 
-	var values []models.Cart
-	for _, value := range repos.Carts {
-		values = append(values, value)
-	}
-
-	if err := json.NewEncoder(w).Encode(values); err != nil {
+	if err := json.NewEncoder(w).Encode(repos.RepoFindAllCarts()); err != nil {
 		panic(err)
 	}
 }
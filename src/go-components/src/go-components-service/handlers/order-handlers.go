@@ -83,6 +83,15 @@ func OrderUpdate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := order.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(422) // unprocessable entity
+		if err := json.NewEncoder(w).Encode(err); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	t := repos.RepoUpdateOrder(order)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
@@ -115,6 +124,15 @@ func OrderCreate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := order.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(422) // unprocessable entity
+		if err := json.NewEncoder(w).Encode(err); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	t := repos.RepoCreateOrder(order)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
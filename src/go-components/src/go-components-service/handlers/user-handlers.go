@@ -227,6 +227,15 @@ func UserUpdate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := user.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(422) // unprocessable entity
+		if err := json.NewEncoder(w).Encode(err); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	t := repos.RepoUpdateUser(user)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
@@ -259,6 +268,15 @@ func UserCreate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := user.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(422) // unprocessable entity
+		if err := json.NewEncoder(w).Encode(err); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	t, err := repos.RepoCreateUser(user)
 	if err != nil {
 		panic(err)
@@ -5,6 +5,8 @@ package models
 
 import (
 	"time"
+
+	"shared/address"
 )
 
 // User Struct
@@ -30,17 +32,7 @@ type User struct {
 type Users []User
 
 // Address Struct
-type Address struct {
-	FirstName string `json:"first_name" yaml:"first_name"`
-	LastName  string `json:"last_name" yaml:"last_name"`
-	Address1  string `json:"address1" yaml:"address1"`
-	Address2  string `json:"address2" yaml:"address2"`
-	Country   string `json:"country" yaml:"country"`
-	City      string `json:"city" yaml:"city"`
-	State     string `json:"state" yaml:"state"`
-	ZipCode   string `json:"zipcode" yaml:"zipcode"`
-	Default   bool   `json:"default" yaml:"default"`
-}
+type Address = address.Address
 
 // Addresses Struct
 type Addresses []Address
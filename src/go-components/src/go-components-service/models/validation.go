@@ -0,0 +1,44 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package models
+
+import (
+	"strconv"
+
+	"shared/address"
+	"shared/validation"
+)
+
+// ValidationError is returned when a request has one or more invalid fields
+type ValidationError = validation.Error
+
+// Validate normalizes the user's addresses and checks each of them is
+// complete for its country
+func (u *User) Validate() error {
+	verr := &ValidationError{}
+	for i := range u.Addresses {
+		u.Addresses[i] = validateAddress(verr, "addresses["+strconv.Itoa(i)+"]", u.Addresses[i])
+	}
+	return verr.OrNil()
+}
+
+// Validate normalizes the order's addresses and checks each one given is
+// complete for its country
+func (o *Order) Validate() error {
+	verr := &ValidationError{}
+	if o.BillingAddress != (Address{}) {
+		o.BillingAddress = validateAddress(verr, "billing_address", o.BillingAddress)
+	}
+	if o.ShippingAddress != (Address{}) {
+		o.ShippingAddress = validateAddress(verr, "shipping_address", o.ShippingAddress)
+	}
+	return verr.OrNil()
+}
+
+// validateAddress normalizes the address and records its problems under field
+func validateAddress(verr *ValidationError, field string, a Address) Address {
+	a = address.Normalize(a)
+	verr.AddAll(field, address.Validate(a))
+	return a
+}
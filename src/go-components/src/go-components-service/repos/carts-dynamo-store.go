@@ -0,0 +1,128 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package repos
+
+import (
+	"go-component-service/models"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	guuuid "github.com/google/uuid"
+)
+
+// DynamoCartStore persists carts in a DynamoDB table keyed by "id"
+type DynamoCartStore struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewDynamoCartStore Function
+func NewDynamoCartStore(client *dynamodb.DynamoDB, tableName string) *DynamoCartStore {
+	return &DynamoCartStore{client: client, tableName: tableName}
+}
+
+// FindAll Function
+func (s *DynamoCartStore) FindAll() ([]models.Cart, error) {
+	values := []models.Cart{}
+
+	params := &dynamodb.ScanInput{
+		TableName: aws.String(s.tableName),
+	}
+
+	var unmarshalErr error
+	err := s.client.ScanPages(params, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var carts []models.Cart
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &carts); unmarshalErr != nil {
+			return false
+		}
+		values = append(values, carts...)
+		return true
+	})
+
+	if err != nil {
+		log.Println("Got error scanning carts:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return values, unmarshalErr
+}
+
+// FindByID Function
+func (s *DynamoCartStore) FindByID(id string) (models.Cart, error) {
+	var cart models.Cart
+
+	result, err := s.client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+
+	if err != nil {
+		log.Println("get item error " + string(err.Error()))
+		return cart, err
+	}
+
+	if result.Item == nil {
+		return cart, ErrCartNotFound
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &cart)
+	return cart, err
+}
+
+// Create Function
+func (s *DynamoCartStore) Create(cart models.Cart) (models.Cart, error) {
+	cart.ID = strings.ToLower(guuuid.New().String())
+
+	if err := s.put(cart, "attribute_not_exists(id)"); err != nil {
+		return models.Cart{}, err
+	}
+
+	return cart, nil
+}
+
+// Update Function
+func (s *DynamoCartStore) Update(cart models.Cart) (models.Cart, error) {
+	err := s.put(cart, "attribute_exists(id)")
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return models.Cart{}, ErrCartNotFound
+	}
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	return cart, nil
+}
+
+func (s *DynamoCartStore) put(cart models.Cart, condition string) error {
+	av, err := dynamodbattribute.MarshalMap(cart)
+	if err != nil {
+		log.Println("Got error calling dynamodbattribute MarshalMap:")
+		log.Println(err.Error())
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:                av,
+		TableName:           aws.String(s.tableName),
+		ConditionExpression: aws.String(condition),
+	}
+
+	_, err = s.client.PutItem(input)
+	if err != nil {
+		log.Println("Got error calling PutItem:")
+		log.Println(err.Error())
+	}
+
+	return err
+}
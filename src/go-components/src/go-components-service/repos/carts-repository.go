@@ -5,17 +5,33 @@ package repos
 
 import (
 	"go-component-service/models"
-	"strconv"
+	"log"
 )
 
-var currentCartID int
+var cartStore CartStore
 
-var Carts = map[string]models.Cart{}
+// Init
+func init() {
+	cartStore = NewCartStore()
+}
+
+// RepoFindAllCarts Function
+func RepoFindAllCarts() []models.Cart {
+	values, err := cartStore.FindAll()
+	if err != nil {
+		log.Println("RepoFindAllCarts error: ", err)
+		return []models.Cart{}
+	}
+	return values
+}
 
 // RepoFindCartByID Function
 func RepoFindCartByID(id string) models.Cart {
-	cart, ok := Carts[id]
-	if !ok {
+	cart, err := cartStore.FindByID(id)
+	if err != nil {
+		if err != ErrCartNotFound {
+			log.Println("RepoFindCartByID error: ", err)
+		}
 		return models.Cart{}
 	}
 	return cart
@@ -23,23 +39,26 @@ func RepoFindCartByID(id string) models.Cart {
 
 // RepoUpdateCart Function
 func RepoUpdateCart(id string, cart models.Cart) models.Cart {
-	_, ok := Carts[id]
+	cart.ID = id
 
-	if !ok {
+	updated, err := cartStore.Update(cart)
+	if err != nil {
+		if err != ErrCartNotFound {
+			log.Println("RepoUpdateCart error: ", err)
+		}
 		// return empty Cart if not found
 		return models.Cart{}
 	}
 
-	cart.ID = id
-	Carts[id] = cart
-
-	return cart
+	return updated
 }
 
 // RepoCreateCart Function
 func RepoCreateCart(t models.Cart) models.Cart {
-	currentCartID++
-	t.ID = strconv.Itoa(currentCartID)
-	Carts[t.ID] = t
-	return t
+	created, err := cartStore.Create(t)
+	if err != nil {
+		log.Println("RepoCreateCart error: ", err)
+		return models.Cart{}
+	}
+	return created
 }
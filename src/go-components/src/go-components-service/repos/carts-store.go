@@ -0,0 +1,100 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package repos
+
+import (
+	"errors"
+	"go-component-service/models"
+	"go-component-service/util"
+	"log"
+	"strconv"
+	"sync"
+)
+
+// ErrCartNotFound is returned by a CartStore when no cart exists for an ID
+var ErrCartNotFound = errors.New("Cart not found")
+
+// CartStore persists carts. The repository functions delegate to the store
+// selected at startup so handlers don't need to know which backend is in use.
+type CartStore interface {
+	// FindAll returns every cart in the store
+	FindAll() ([]models.Cart, error)
+	// FindByID returns the cart for id or ErrCartNotFound
+	FindByID(id string) (models.Cart, error)
+	// Create assigns a new ID to cart and persists it
+	Create(cart models.Cart) (models.Cart, error)
+	// Update replaces an existing cart or returns ErrCartNotFound
+	Update(cart models.Cart) (models.Cart, error)
+}
+
+// NewCartStore returns a DynamoDB backed store when a carts table is
+// configured, otherwise an in-memory store.
+func NewCartStore() CartStore {
+	if len(util.DbTableCarts) > 0 {
+		log.Println("Using DynamoDB cart store with table: ", util.DbTableCarts)
+		return NewDynamoCartStore(util.DynamoClient, util.DbTableCarts)
+	}
+
+	log.Println("Using in-memory cart store")
+	return NewMemoryCartStore()
+}
+
+// MemoryCartStore keeps carts in process memory. Carts are lost on restart.
+type MemoryCartStore struct {
+	mu        sync.RWMutex
+	currentID int
+	carts     map[string]models.Cart
+}
+
+// NewMemoryCartStore Function
+func NewMemoryCartStore() *MemoryCartStore {
+	return &MemoryCartStore{carts: map[string]models.Cart{}}
+}
+
+// FindAll Function
+func (s *MemoryCartStore) FindAll() ([]models.Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make([]models.Cart, 0, len(s.carts))
+	for _, value := range s.carts {
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// FindByID Function
+func (s *MemoryCartStore) FindByID(id string) (models.Cart, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cart, ok := s.carts[id]
+	if !ok {
+		return models.Cart{}, ErrCartNotFound
+	}
+	return cart, nil
+}
+
+// Create Function
+func (s *MemoryCartStore) Create(cart models.Cart) (models.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.currentID++
+	cart.ID = strconv.Itoa(s.currentID)
+	s.carts[cart.ID] = cart
+	return cart, nil
+}
+
+// Update Function
+func (s *MemoryCartStore) Update(cart models.Cart) (models.Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.carts[cart.ID]; !ok {
+		return models.Cart{}, ErrCartNotFound
+	}
+	s.carts[cart.ID] = cart
+	return cart, nil
+}
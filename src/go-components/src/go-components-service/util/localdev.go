@@ -77,6 +77,52 @@ func loadData() {
 	}
 
 	log.Println("Successfully loaded product and category data into DDB")
+
+	if len(DbTableCarts) > 0 {
+		err = createCartsTable()
+		if err != nil {
+			log.Panic("Unable to create carts table.")
+		}
+	}
+}
+
+func createCartsTable() error {
+	log.Println("Creating carts table: ", DbTableCarts)
+
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		TableName:   aws.String(DbTableCarts),
+	}
+
+	_, err := DynamoClient.CreateTable(input)
+	if err != nil {
+		log.Println("Error creating carts table: ", DbTableCarts)
+
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() == dynamodb.ErrCodeResourceInUseException {
+				log.Println("Table already exists; continuing")
+				err = nil
+			} else {
+				log.Println(err.Error())
+			}
+		} else {
+			log.Println(err.Error())
+		}
+	}
+
+	return err
 }
 
 func createProductsTable() error {
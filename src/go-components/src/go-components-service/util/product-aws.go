@@ -19,6 +19,10 @@ var Pro_sess, Pro_err = session.NewSession(&aws.Config{})
 var DbTableProducts = os.Getenv("DDB_TABLE_PRODUCTS")
 var DbTableCategories = os.Getenv("DDB_TABLE_CATEGORIES")
 
+// Carts are kept in memory unless a carts table is configured. The table is
+// separate from the carts service's table, whose carts have a different shape.
+var DbTableCarts = os.Getenv("DDB_TABLE_COMPONENT_CARTS")
+
 // Allow DDB endpoint to be overridden to support amazon/dynamodb-local
 var DdbEndpointOverride = os.Getenv("DDB_ENDPOINT_OVERRIDE")
 var RunningLocal bool
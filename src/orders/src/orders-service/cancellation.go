@@ -0,0 +1,140 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// errOrderAlreadyCancelled aborts saving a cancellation when the order was
+// cancelled by another request in the meantime
+var errOrderAlreadyCancelled = errors.New("Order has already been cancelled")
+
+// errRestockClaimed aborts claiming a cancelled order's restock when it has
+// already been restocked or another request is restocking it
+var errRestockClaimed = errors.New("Order restock has already been claimed")
+
+// Cancellation Struct - why and by whom an order was cancelled. Restocked is
+// set once the order's stock has been added back to inventory.
+type Cancellation struct {
+	Reason         string    `json:"reason" yaml:"reason"`
+	Actor          string    `json:"actor" yaml:"actor"`
+	Timestamp      time.Time `json:"timestamp" yaml:"timestamp"`
+	Restocked      bool      `json:"restocked" yaml:"restocked"`
+	RestockClaimed bool      `json:"-" yaml:"-" dynamodbav:"restock_claimed,omitempty"` // set while a request restocks the order
+}
+
+// CancelRequest Struct - request body for cancelling an order
+type CancelRequest struct {
+	Reason string `json:"reason" yaml:"reason"`
+	Actor  string `json:"actor" yaml:"actor"`
+}
+
+// Cancel cancels the order and records why. Orders can only be cancelled
+// before anything has shipped. Cancelling an order that is already cancelled
+// changes nothing and returns false.
+func (o *Order) Cancel(request CancelRequest, now time.Time) (bool, error) {
+	if o.Status == StatusCancelled {
+		return false, nil
+	}
+
+	if err := o.Transition(StatusCancelled, request.Actor, request.Reason, now); err != nil {
+		return false, err
+	}
+
+	change := o.History[len(o.History)-1]
+	o.Cancellation = &Cancellation{
+		Reason:    request.Reason,
+		Actor:     change.Actor,
+		Timestamp: change.Timestamp,
+	}
+	return true, nil
+}
+
+// RepoCancelOrder Function
+// The cancellation is saved first, then the stock the order took at checkout
+// is added back to inventory in the products service. Orders created without
+// a cart never took stock and aren't restocked. Only the request that claims
+// the restock on the stored order adds stock back, from the items stored with
+// the order. If the inventory can't be updated, stock already added is taken
+// out again and the claim is released, so the cancelled order is restocked
+// when the request is retried. Cancelling an order that is already cancelled
+// and restocked returns it unchanged.
+func RepoCancelOrder(id string, request CancelRequest) (Order, error) {
+	order, err := RepoMutateOrder(id, func(o *Order) error {
+		cancelled, err := o.Cancel(request, time.Now())
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return errOrderAlreadyCancelled
+		}
+		return nil
+	})
+	if err == errOrderAlreadyCancelled {
+		order, err = orderRepository.FindByID(id)
+	} else if err == nil {
+		releasePickupSlot(order.ID, order.Pickup)
+	}
+	if err != nil {
+		return Order{}, err
+	}
+
+	if !order.StockTaken || order.Cancellation.Restocked {
+		return order, nil
+	}
+	return restockCancelledOrder(id)
+}
+
+// restockCancelledOrder claims the restock of a cancelled order, adds its
+// stock back and marks it restocked
+func restockCancelledOrder(id string) (Order, error) {
+	var items ReturnItems
+	_, err := RepoMutateOrder(id, func(o *Order) error {
+		if o.Cancellation == nil || o.Cancellation.Restocked || o.Cancellation.RestockClaimed {
+			return errRestockClaimed
+		}
+		o.Cancellation.RestockClaimed = true
+		items = stockItems(o.Items)
+		return nil
+	})
+	if err == errRestockClaimed {
+		return orderRepository.FindByID(id)
+	}
+	if err != nil {
+		return Order{}, err
+	}
+
+	restocked, err := restockItems(items)
+	if err != nil {
+		unrestockItems(restocked)
+		releaseRestockClaim(id)
+		return Order{}, err
+	}
+
+	updated, err := RepoMutateOrder(id, func(o *Order) error {
+		o.Cancellation.Restocked = true
+		o.Cancellation.RestockClaimed = false
+		return nil
+	})
+	if err != nil {
+		// The stock is back; the claim stops it being added again
+		log.Println("restockCancelledOrder unable to mark order restocked: ", id, err)
+		return Order{}, err
+	}
+	return updated, nil
+}
+
+// releaseRestockClaim lets a failed restock be retried
+func releaseRestockClaim(id string) {
+	_, err := RepoMutateOrder(id, func(o *Order) error {
+		o.Cancellation.RestockClaimed = false
+		return nil
+	})
+	if err != nil {
+		log.Println("releaseRestockClaim error: ", id, err)
+	}
+}
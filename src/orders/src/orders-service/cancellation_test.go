@@ -0,0 +1,244 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// useFakeCatalog points the repository functions at a new fake catalog and
+// returns it with a function that puts the real catalog back
+func useFakeCatalog() (*fakeCatalog, func()) {
+	catalog := newFakeCatalog()
+	saved := productCatalog
+	productCatalog = catalog
+	return catalog, func() { productCatalog = saved }
+}
+
+func newTestOrder(cartID string) Order {
+	return Order{
+		Username:        "tester",
+		CartID:          cartID,
+		Items:           OrderItems{{ProductID: "shirt", Quantity: 2}, {ProductID: "apple", Quantity: 1}, {ProductID: "shirt", Quantity: 1}},
+		BillingAddress:  Address{FirstName: "Ada", LastName: "Lovelace", Address1: "1 Main St", City: "Seattle", State: "WA", ZipCode: "98101", Country: "US"},
+		ShippingAddress: Address{FirstName: "Ada", LastName: "Lovelace", Address1: "1 Main St", City: "Seattle", State: "WA", ZipCode: "98101", Country: "US"},
+		DeliveryType:    DeliveryTypeDelivery,
+	}
+}
+
+func TestCreateOrderTakesCartStock(t *testing.T) {
+	tests := []struct {
+		name           string
+		cartID         string
+		stock          map[string]int
+		wantErr        bool
+		wantStockTaken bool
+		wantDeltas     map[string]int
+	}{
+		{name: "cart checkout", cartID: "cart-1", wantStockTaken: true, wantDeltas: map[string]int{"shirt": -3, "apple": -1}},
+		{name: "direct order", cartID: "", wantStockTaken: true, wantDeltas: map[string]int{"shirt": -3, "apple": -1}},
+		{name: "out of stock", cartID: "cart-1", stock: map[string]int{"apple": 0}, wantErr: true, wantDeltas: map[string]int{"shirt": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog, restore := useFakeCatalog()
+			defer restore()
+			for id, quantity := range tt.stock {
+				catalog.stock[id] = quantity
+			}
+
+			order := newTestOrder(tt.cartID)
+			order.StockTaken = true
+			created, err := RepoCreateOrder(order)
+			if tt.wantErr {
+				if _, ok := err.(*OutOfStockError); !ok {
+					t.Fatalf("RepoCreateOrder() error = %v, want out of stock", err)
+				}
+			} else if err != nil {
+				t.Fatalf("RepoCreateOrder() error = %v", err)
+			}
+
+			if created.StockTaken != tt.wantStockTaken {
+				t.Errorf("StockTaken = %v, want %v", created.StockTaken, tt.wantStockTaken)
+			}
+			if len(catalog.deltas) != len(tt.wantDeltas) {
+				t.Fatalf("deltas = %v, want %v", catalog.deltas, tt.wantDeltas)
+			}
+			for id, want := range tt.wantDeltas {
+				if catalog.deltas[id] != want {
+					t.Errorf("deltas = %v, want %v", catalog.deltas, tt.wantDeltas)
+				}
+			}
+		})
+	}
+}
+
+func TestTakeStockReleasesFailedCommit(t *testing.T) {
+	catalog, restore := useFakeCatalog()
+	defer restore()
+	catalog.commitFails = "apple"
+
+	if err := takeStock("cart-1", newTestOrder("cart-1").Items); err != ErrInventoryUnavailable {
+		t.Fatalf("takeStock() error = %v, want %v", err, ErrInventoryUnavailable)
+	}
+	if reserved := catalog.reserved["apple/cart-1"]; reserved != 0 {
+		t.Errorf("apple reservation = %d, want released", reserved)
+	}
+	if catalog.deltas["shirt"] != 0 {
+		t.Errorf("shirt delta = %d, want the stock taken added back", catalog.deltas["shirt"])
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		cartID        string
+		prepare       func(id string) error
+		wantErr       bool
+		wantStatus    string
+		wantRestocked bool
+		wantDeltas    map[string]int
+	}{
+		{
+			name:          "cart order is restocked",
+			cartID:        "cart-1",
+			wantStatus:    StatusCancelled,
+			wantRestocked: true,
+			wantDeltas:    map[string]int{"shirt": 0, "apple": 0},
+		},
+		{
+			name:          "direct order is restocked",
+			cartID:        "",
+			wantStatus:    StatusCancelled,
+			wantRestocked: true,
+			wantDeltas:    map[string]int{"shirt": 0, "apple": 0},
+		},
+		{
+			name:   "restocks the stored items, not updated ones",
+			cartID: "cart-1",
+			prepare: func(id string) error {
+				update := newTestOrder("other-cart")
+				update.ID = id
+				update.Items = OrderItems{{ProductID: "shirt", Quantity: 10}}
+				update.StockTaken = false
+				_, err := RepoUpdateOrder(update)
+				return err
+			},
+			wantStatus:    StatusCancelled,
+			wantRestocked: true,
+			wantDeltas:    map[string]int{"shirt": 0, "apple": 0},
+		},
+		{
+			name:   "already restocked orders aren't restocked again",
+			cartID: "cart-1",
+			prepare: func(id string) error {
+				_, err := RepoCancelOrder(id, CancelRequest{Reason: "first"})
+				return err
+			},
+			wantStatus:    StatusCancelled,
+			wantRestocked: true,
+			wantDeltas:    map[string]int{"shirt": 0, "apple": 0},
+		},
+		{
+			name:   "orders being restocked by another request",
+			cartID: "cart-1",
+			prepare: func(id string) error {
+				_, err := RepoMutateOrder(id, func(o *Order) error {
+					o.Cancel(CancelRequest{}, o.CreatedAt)
+					o.Cancellation.RestockClaimed = true
+					return nil
+				})
+				return err
+			},
+			wantStatus: StatusCancelled,
+			wantDeltas: map[string]int{"shirt": -3, "apple": -1},
+		},
+		{
+			name:   "shipped orders can't be cancelled",
+			cartID: "cart-1",
+			prepare: func(id string) error {
+				_, err := RepoMutateOrder(id, func(o *Order) error {
+					o.setStatus(StatusShipped, "", "", o.CreatedAt)
+					return nil
+				})
+				return err
+			},
+			wantErr:    true,
+			wantStatus: StatusShipped,
+			wantDeltas: map[string]int{"shirt": -3, "apple": -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog, restore := useFakeCatalog()
+			defer restore()
+
+			created, err := RepoCreateOrder(newTestOrder(tt.cartID))
+			if err != nil {
+				t.Fatalf("RepoCreateOrder() error = %v", err)
+			}
+			if tt.prepare != nil {
+				if err := tt.prepare(created.ID); err != nil {
+					t.Fatalf("prepare error = %v", err)
+				}
+			}
+
+			_, err = RepoCancelOrder(created.ID, CancelRequest{Reason: "changed my mind", Actor: "tester"})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("RepoCancelOrder() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			stored := RepoFindOrderByID(created.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if restocked := stored.Cancellation != nil && stored.Cancellation.Restocked; restocked != tt.wantRestocked {
+				t.Errorf("Restocked = %v, want %v", restocked, tt.wantRestocked)
+			}
+			if len(catalog.deltas) != len(tt.wantDeltas) {
+				t.Fatalf("deltas = %v, want %v", catalog.deltas, tt.wantDeltas)
+			}
+			for id, want := range tt.wantDeltas {
+				if catalog.deltas[id] != want {
+					t.Errorf("deltas = %v, want %v", catalog.deltas, tt.wantDeltas)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelOrderRetriesFailedRestock(t *testing.T) {
+	catalog, restore := useFakeCatalog()
+	defer restore()
+
+	created, err := RepoCreateOrder(newTestOrder("cart-1"))
+	if err != nil {
+		t.Fatalf("RepoCreateOrder() error = %v", err)
+	}
+
+	catalog.err = errors.New("connection refused")
+	if _, err := RepoCancelOrder(created.ID, CancelRequest{}); err != ErrInventoryUnavailable {
+		t.Fatalf("RepoCancelOrder() error = %v, want %v", err, ErrInventoryUnavailable)
+	}
+
+	stored := RepoFindOrderByID(created.ID)
+	if stored.Status != StatusCancelled || stored.Cancellation.Restocked || stored.Cancellation.RestockClaimed {
+		t.Fatalf("after failed restock Status = %q, Cancellation = %+v, want cancelled and unclaimed", stored.Status, stored.Cancellation)
+	}
+
+	catalog.err = nil
+	cancelled, err := RepoCancelOrder(created.ID, CancelRequest{})
+	if err != nil {
+		t.Fatalf("RepoCancelOrder() retry error = %v", err)
+	}
+	if !cancelled.Cancellation.Restocked {
+		t.Errorf("Restocked = false after retry")
+	}
+	if catalog.deltas["shirt"] != 0 || catalog.deltas["apple"] != 0 {
+		t.Errorf("deltas = %v, want all zero", catalog.deltas)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"shared/catalog"
+	"shared/services"
+)
+
+// Products service location passed via environment (local development).
+// When not set, the products service is discovered through Cloud Map.
+var productsService = services.NewEndpoint("products", os.Getenv("PRODUCT_SERVICE_HOST"), os.Getenv("PRODUCT_SERVICE_PORT"), sess)
+
+// CatalogProduct Struct - the fields of a products service Product
+type CatalogProduct = catalog.Product
+
+// ProductCatalog looks up products in the products service
+type ProductCatalog interface {
+	// FindProducts returns the products that exist for ids keyed by product ID
+	FindProducts(ids []string) (map[string]CatalogProduct, error)
+	// UpdateInventory adds stockDelta (which may be negative) to a product's stock
+	UpdateInventory(productID string, stockDelta int) error
+	// Reserve sets the quantity of a product held by a reservation
+	Reserve(productID string, reservationID string, quantity int) error
+	// CommitReservation converts a reservation into a stock decrement
+	CommitReservation(productID string, reservationID string) error
+}
+
+var productCatalog ProductCatalog = NewHTTPProductCatalog()
+
+// HTTPProductCatalog calls the products service REST API
+type HTTPProductCatalog struct {
+	client   *http.Client
+	endpoint *services.Endpoint
+}
+
+// NewHTTPProductCatalog Function
+func NewHTTPProductCatalog() *HTTPProductCatalog {
+	return &HTTPProductCatalog{client: &http.Client{Timeout: 5 * time.Second}, endpoint: productsService}
+}
+
+// FindProducts Function
+func (c *HTTPProductCatalog) FindProducts(ids []string) (map[string]CatalogProduct, error) {
+	return catalog.FindProducts(c.client, c.endpoint, ids)
+}
+
+// inventoryUpdate Struct - request body of the products service inventory API
+type inventoryUpdate struct {
+	StockDelta int `json:"stock_delta"`
+}
+
+// UpdateInventory Function
+func (c *HTTPProductCatalog) UpdateInventory(productID string, stockDelta int) error {
+	path := "/products/id/" + url.PathEscape(productID) + "/inventory"
+
+	_, status, err := c.endpoint.Do(c.client, "PUT", path, inventoryUpdate{StockDelta: stockDelta}, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("products service returned status %d updating inventory for %s", status, productID)
+	}
+
+	return nil
+}
+
+// reservationRequest Struct - request body of the products service reservation API
+type reservationRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// Reserve Function
+func (c *HTTPProductCatalog) Reserve(productID string, reservationID string, quantity int) error {
+	path := "/products/id/" + url.PathEscape(productID) + "/reservations/" + url.PathEscape(reservationID)
+
+	body, status, err := c.endpoint.Do(c.client, "PUT", path, reservationRequest{Quantity: quantity}, nil)
+	if err != nil {
+		return err
+	}
+	return reservationError(productID, status, body)
+}
+
+// CommitReservation Function
+func (c *HTTPProductCatalog) CommitReservation(productID string, reservationID string) error {
+	path := "/products/id/" + url.PathEscape(productID) + "/reservations/" + url.PathEscape(reservationID) + "/commit"
+
+	body, status, err := c.endpoint.Do(c.client, "POST", path, nil, nil)
+	if err != nil {
+		return err
+	}
+	return reservationError(productID, status, body)
+}
+
+// reservationError maps a products service reservation response to an error
+func reservationError(productID string, status int, body []byte) error {
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		var shortage StockShortage
+		if err := json.Unmarshal(body, &shortage); err == nil && len(shortage.ProductID) > 0 {
+			return &OutOfStockError{Items: []StockShortage{shortage}}
+		}
+	}
+	return fmt.Errorf("products service returned status %d updating reservation for %s", status, productID)
+}
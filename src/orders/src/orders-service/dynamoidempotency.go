@@ -0,0 +1,188 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// DynamoIdempotencyStore keeps records in a DynamoDB table keyed by
+// "idempotency_key". Enable TTL on "expires_at" so expired records are removed.
+type DynamoIdempotencyStore struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewDynamoIdempotencyStore Function
+func NewDynamoIdempotencyStore(client *dynamodb.DynamoDB, tableName string) *DynamoIdempotencyStore {
+	return &DynamoIdempotencyStore{client: client, tableName: tableName}
+}
+
+// Begin Function
+func (s *DynamoIdempotencyStore) Begin(record IdempotencyRecord) (IdempotencyRecord, error) {
+	// TTL deletion lags expiry, so expired and abandoned records are
+	// overwritten here rather than waiting for them to disappear
+	now := expression.Value(time.Now().Unix())
+	condition := expression.AttributeNotExists(expression.Name("idempotency_key")).
+		Or(expression.Name("expires_at").LessThanEqual(now)).
+		Or(expression.Name("locked_until").LessThanEqual(now))
+
+	err := s.put(record, &condition)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		existing, err := s.find(record.Key)
+		if err != nil {
+			return IdempotencyRecord{}, err
+		}
+		return existing, ErrIdempotencyKeyExists
+	}
+	if err != nil {
+		return IdempotencyRecord{}, err
+	}
+
+	return record, nil
+}
+
+// Extend Function
+func (s *DynamoIdempotencyStore) Extend(key string, lockToken string, lockedUntil int64) error {
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("locked_until"), expression.Value(lockedUntil))).
+		WithCondition(heldWith(lockToken)).
+		Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	_, err = s.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       idempotencyKey(key),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrIdempotencyLockLost
+	}
+	if err != nil {
+		log.Println("Got error calling UpdateItem:")
+		log.Println(err.Error())
+	}
+	return err
+}
+
+// Complete Function
+func (s *DynamoIdempotencyStore) Complete(record IdempotencyRecord) error {
+	condition := heldWith(record.LockToken)
+	record.LockToken = ""
+	record.LockedUntil = 0
+
+	err := s.put(record, &condition)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrIdempotencyLockLost
+	}
+	return err
+}
+
+// Release Function
+func (s *DynamoIdempotencyStore) Release(key string, lockToken string) error {
+	expr, err := expression.NewBuilder().WithCondition(heldWith(lockToken)).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	_, err = s.client.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       idempotencyKey(key),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrIdempotencyLockLost
+	}
+	if err != nil {
+		log.Println("Got error calling DeleteItem:")
+		log.Println(err.Error())
+	}
+	return err
+}
+
+// heldWith is the condition that the record is still locked by the request
+// holding lockToken
+func heldWith(lockToken string) expression.ConditionBuilder {
+	return expression.Name("lock_token").Equal(expression.Value(lockToken))
+}
+
+func idempotencyKey(key string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"idempotency_key": {
+			S: aws.String(key),
+		},
+	}
+}
+
+// find returns the stored record for key
+func (s *DynamoIdempotencyStore) find(key string) (IdempotencyRecord, error) {
+	var record IdempotencyRecord
+
+	result, err := s.client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key:            idempotencyKey(key),
+	})
+	if err != nil {
+		log.Println("get item error " + string(err.Error()))
+		return record, err
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &record)
+	return record, err
+}
+
+func (s *DynamoIdempotencyStore) put(record IdempotencyRecord, condition *expression.ConditionBuilder) error {
+	av, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		log.Println("Got error calling dynamodbattribute MarshalMap:")
+		log.Println(err.Error())
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(s.tableName),
+	}
+
+	if condition != nil {
+		expr, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			log.Println("Got error building expression:")
+			log.Println(err.Error())
+			return err
+		}
+		input.ConditionExpression = expr.Condition()
+		input.ExpressionAttributeNames = expr.Names()
+		input.ExpressionAttributeValues = expr.Values()
+	}
+
+	_, err = s.client.PutItem(input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+			log.Println("Got error calling PutItem:")
+			log.Println(err.Error())
+		}
+	}
+
+	return err
+}
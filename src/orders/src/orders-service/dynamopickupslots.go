@@ -0,0 +1,131 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// DynamoPickupSlotStore keeps one item per booked slot in a DynamoDB table
+// keyed by "store_id" and "slot_start" (epoch seconds), holding the booked
+// order IDs in the "order_ids" string set
+type DynamoPickupSlotStore struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+// dynamoPickupSlot Struct - an item in the pickup slots table
+type dynamoPickupSlot struct {
+	StoreID   string   `dynamodbav:"store_id"`
+	SlotStart int64    `dynamodbav:"slot_start"`
+	OrderIDs  []string `dynamodbav:"order_ids,stringset,omitempty"`
+}
+
+// NewDynamoPickupSlotStore Function
+func NewDynamoPickupSlotStore(client *dynamodb.DynamoDB, tableName string) *DynamoPickupSlotStore {
+	return &DynamoPickupSlotStore{client: client, tableName: tableName}
+}
+
+// Reserve Function
+func (s *DynamoPickupSlotStore) Reserve(storeID string, start time.Time, orderID string, capacity int) error {
+	// The condition is checked against the stored item, so concurrent
+	// bookings can't take the slot past its capacity
+	_, err := s.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.tableName),
+		Key:                 s.key(storeID, start),
+		UpdateExpression:    aws.String("ADD order_ids :ids"),
+		ConditionExpression: aws.String("attribute_not_exists(order_ids) OR size(order_ids) < :capacity OR contains(order_ids, :id)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":ids":      {SS: []*string{aws.String(orderID)}},
+			":id":       {S: aws.String(orderID)},
+			":capacity": {N: aws.String(strconv.Itoa(capacity))},
+		},
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrPickupSlotFull
+	}
+	if err != nil {
+		log.Println("Got error calling UpdateItem:")
+		log.Println(err.Error())
+	}
+	return err
+}
+
+// Release Function
+func (s *DynamoPickupSlotStore) Release(storeID string, start time.Time, orderID string) error {
+	_, err := s.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:        aws.String(s.tableName),
+		Key:              s.key(storeID, start),
+		UpdateExpression: aws.String("DELETE order_ids :ids"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":ids": {SS: []*string{aws.String(orderID)}},
+		},
+	})
+	if err != nil {
+		log.Println("Got error calling UpdateItem:")
+		log.Println(err.Error())
+	}
+	return err
+}
+
+// Booked Function
+func (s *DynamoPickupSlotStore) Booked(storeID string, from time.Time, to time.Time) (map[time.Time]int, error) {
+	booked := map[time.Time]int{}
+
+	keycond := expression.Key("store_id").Equal(expression.Value(storeID)).
+		And(expression.Key("slot_start").Between(expression.Value(from.Unix()), expression.Value(to.Unix()-1)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keycond).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String(s.tableName),
+		ConsistentRead:            aws.Bool(true),
+	}
+
+	var unmarshalErr error
+	err = s.client.QueryPages(params, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var slots []dynamoPickupSlot
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &slots); unmarshalErr != nil {
+			return false
+		}
+		for _, slot := range slots {
+			booked[time.Unix(slot.SlotStart, 0).UTC()] = len(slot.OrderIDs)
+		}
+		return true
+	})
+
+	if err != nil {
+		log.Println("Got error QUERY expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return booked, unmarshalErr
+}
+
+func (s *DynamoPickupSlotStore) key(storeID string, start time.Time) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"store_id": {
+			S: aws.String(storeID),
+		},
+		"slot_start": {
+			N: aws.String(strconv.FormatInt(start.Unix(), 10)),
+		},
+	}
+}
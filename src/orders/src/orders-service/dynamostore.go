@@ -0,0 +1,392 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	guuuid "github.com/google/uuid"
+)
+
+// DynamoOrderRepository persists orders in a DynamoDB table keyed by "id"
+// with "username-created-index" and "status-created-index" global secondary
+// indexes, keyed by "username" and "status" and sorted by "created_at", and a
+// sparse "outbox-index" on "outbox_pending" for orders with unpublished events
+type DynamoOrderRepository struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+// NewDynamoOrderRepository Function
+func NewDynamoOrderRepository(client *dynamodb.DynamoDB, tableName string) *DynamoOrderRepository {
+	return &DynamoOrderRepository{client: client, tableName: tableName}
+}
+
+// FindAll Function
+func (s *DynamoOrderRepository) FindAll() (Orders, error) {
+	values := Orders{}
+
+	params := &dynamodb.ScanInput{
+		TableName: aws.String(s.tableName),
+	}
+
+	var unmarshalErr error
+	err := s.client.ScanPages(params, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var orders Orders
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &orders); unmarshalErr != nil {
+			return false
+		}
+		values = append(values, orders...)
+		return true
+	})
+
+	if err != nil {
+		log.Println("Got error scanning orders:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return values, unmarshalErr
+}
+
+// FindByID Function
+func (s *DynamoOrderRepository) FindByID(id string) (Order, error) {
+	var order Order
+
+	result, err := s.client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(id),
+			},
+		},
+	})
+
+	if err != nil {
+		log.Println("get item error " + string(err.Error()))
+		return order, err
+	}
+
+	if result.Item == nil {
+		return order, ErrOrderNotFound
+	}
+
+	err = dynamodbattribute.UnmarshalMap(result.Item, &order)
+	return order, err
+}
+
+// FindByUsername Function
+func (s *DynamoOrderRepository) FindByUsername(username string) (Orders, error) {
+	return s.query("username-created-index", "username", username)
+}
+
+// FindByStatus Function
+func (s *DynamoOrderRepository) FindByStatus(status string) (Orders, error) {
+	return s.query("status-created-index", "status", status)
+}
+
+// maxQueryReads bounds the reads made for one page of a query. Filters are
+// applied to the orders read, so when most are filtered out a page can have
+// fewer orders than its limit and still be followed by another.
+const maxQueryReads = 10
+
+// Query Function
+// Queries for a username or status read its index in created_at order, from
+// the key the cursor holds; other queries scan the table. The other filters
+// are applied by DynamoDB to the orders read, and the key of the last order
+// read becomes the next cursor.
+func (s *DynamoOrderRepository) Query(q OrderQuery) (OrderPage, error) {
+	start, err := q.StartKey()
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	var indexName string
+	var keycond expression.KeyConditionBuilder
+	var filters []expression.ConditionBuilder
+	switch q.index() {
+	case "username":
+		indexName = "username-created-index"
+		keycond = expression.Key("username").Equal(expression.Value(q.Username))
+		if len(q.Status) > 0 {
+			filters = append(filters, expression.Name("status").Equal(expression.Value(q.Status)))
+		}
+	case "status":
+		indexName = "status-created-index"
+		keycond = expression.Key("status").Equal(expression.Value(q.Status))
+	}
+
+	// Orders are created on whole seconds, so the range can be given in
+	// whole seconds too and compared as text
+	after, before := ceilSecond(q.CreatedAfter), ceilSecond(q.CreatedBefore)
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return OrderPage{Orders: Orders{}}, nil
+	}
+	if len(indexName) > 0 {
+		switch {
+		case !after.IsZero() && !before.IsZero():
+			keycond = keycond.And(expression.Key("created_at").Between(expression.Value(formatSecond(after)), expression.Value(formatSecond(before.Add(-time.Second)))))
+		case !after.IsZero():
+			keycond = keycond.And(expression.Key("created_at").GreaterThanEqual(expression.Value(formatSecond(after))))
+		case !before.IsZero():
+			keycond = keycond.And(expression.Key("created_at").LessThan(expression.Value(formatSecond(before))))
+		}
+	} else {
+		if !after.IsZero() {
+			filters = append(filters, expression.Name("created_at").GreaterThanEqual(expression.Value(formatSecond(after))))
+		}
+		if !before.IsZero() {
+			filters = append(filters, expression.Name("created_at").LessThan(expression.Value(formatSecond(before))))
+		}
+	}
+
+	if len(q.DeliveryType) > 0 {
+		filters = append(filters, expression.Name("delivery_type").Equal(expression.Value(q.DeliveryType)))
+	}
+	if len(q.Channel) > 0 {
+		filters = append(filters, expression.Name("channel").Equal(expression.Value(q.Channel)))
+	}
+	if q.MinTotal != nil {
+		filters = append(filters, expression.Name("total").GreaterThanEqual(expression.Value(*q.MinTotal)))
+	}
+
+	builder := expression.NewBuilder()
+	if len(indexName) > 0 {
+		builder = builder.WithKeyCondition(keycond)
+	}
+	if len(filters) > 0 {
+		filter := filters[0]
+		for _, f := range filters[1:] {
+			filter = filter.And(f)
+		}
+		builder = builder.WithFilter(filter)
+	}
+	var expr expression.Expression
+	if len(indexName) > 0 || len(filters) > 0 {
+		if expr, err = builder.Build(); err != nil {
+			log.Println("Got error building expression:")
+			log.Println(err.Error())
+			return OrderPage{}, err
+		}
+	}
+
+	page := OrderPage{Orders: Orders{}}
+	startKey := attributeKey(start)
+	for reads := 0; reads < maxQueryReads; reads++ {
+		limit := aws.Int64(int64(q.Limit - len(page.Orders)))
+
+		var items []map[string]*dynamodb.AttributeValue
+		if len(indexName) > 0 {
+			result, err := s.client.Query(&dynamodb.QueryInput{
+				TableName:                 aws.String(s.tableName),
+				IndexName:                 aws.String(indexName),
+				KeyConditionExpression:    expr.KeyCondition(),
+				FilterExpression:          expr.Filter(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+				ScanIndexForward:          aws.Bool(q.Sort != SortCreatedAtDesc),
+				ExclusiveStartKey:         startKey,
+				Limit:                     limit,
+			})
+			if err != nil {
+				log.Println("Got error querying orders:")
+				log.Println(err.Error())
+				return OrderPage{}, err
+			}
+			items, startKey = result.Items, result.LastEvaluatedKey
+		} else {
+			result, err := s.client.Scan(&dynamodb.ScanInput{
+				TableName:                 aws.String(s.tableName),
+				FilterExpression:          expr.Filter(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+				ExclusiveStartKey:         startKey,
+				Limit:                     limit,
+			})
+			if err != nil {
+				log.Println("Got error scanning orders:")
+				log.Println(err.Error())
+				return OrderPage{}, err
+			}
+			items, startKey = result.Items, result.LastEvaluatedKey
+		}
+
+		var orders Orders
+		if err := dynamodbattribute.UnmarshalListOfMaps(items, &orders); err != nil {
+			return OrderPage{}, err
+		}
+		page.Orders = append(page.Orders, orders...)
+
+		if len(startKey) == 0 {
+			return page, nil
+		}
+		if len(page.Orders) >= q.Limit {
+			break
+		}
+	}
+
+	page.NextCursor = q.NextCursor(stringKey(startKey))
+	return page, nil
+}
+
+// attributeKey converts a cursor key to a DynamoDB key. Every key attribute
+// of the orders table and its indexes is a string.
+func attributeKey(key map[string]string) map[string]*dynamodb.AttributeValue {
+	if key == nil {
+		return nil
+	}
+	values := make(map[string]*dynamodb.AttributeValue, len(key))
+	for name, value := range key {
+		values[name] = &dynamodb.AttributeValue{S: aws.String(value)}
+	}
+	return values
+}
+
+// stringKey converts a DynamoDB key to a cursor key
+func stringKey(key map[string]*dynamodb.AttributeValue) map[string]string {
+	values := make(map[string]string, len(key))
+	for name, value := range key {
+		values[name] = aws.StringValue(value.S)
+	}
+	return values
+}
+
+// ceilSecond rounds t up to a whole second in UTC
+func ceilSecond(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	t = t.UTC()
+	if truncated := t.Truncate(time.Second); !truncated.Equal(t) {
+		return truncated.Add(time.Second)
+	}
+	return t
+}
+
+// formatSecond formats a whole second the way created_at is stored
+func formatSecond(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// FindWithPendingEvents Function
+func (s *DynamoOrderRepository) FindWithPendingEvents() (Orders, error) {
+	return s.query("outbox-index", "outbox_pending", outboxPending)
+}
+
+// Create Function
+func (s *DynamoOrderRepository) Create(order Order) (Order, error) {
+	order.ID = strings.ToLower(guuuid.New().String())
+	order.Version = 1
+
+	if err := s.put(order, expression.AttributeNotExists(expression.Name("id"))); err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+// Update Function
+func (s *DynamoOrderRepository) Update(order Order) (Order, error) {
+	expected := order.Version
+	order.Version++
+
+	err := s.put(order, expression.Name("version").Equal(expression.Value(expected)))
+
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// Condition fails both for a stale version and a missing order
+		if _, err := s.FindByID(order.ID); err != nil {
+			return Order{}, err
+		}
+		return Order{}, ErrOrderVersionConflict
+	}
+	if err != nil {
+		return Order{}, err
+	}
+
+	return order, nil
+}
+
+// query returns the orders whose attribute equals value using indexName
+func (s *DynamoOrderRepository) query(indexName string, attribute string, value string) (Orders, error) {
+	values := Orders{}
+
+	keycond := expression.Key(attribute).Equal(expression.Value(value))
+	expr, err := expression.NewBuilder().WithKeyCondition(keycond).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String(s.tableName),
+		IndexName:                 aws.String(indexName),
+	}
+
+	var unmarshalErr error
+	err = s.client.QueryPages(params, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		var orders Orders
+		if unmarshalErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &orders); unmarshalErr != nil {
+			return false
+		}
+		values = append(values, orders...)
+		return true
+	})
+
+	if err != nil {
+		log.Println("Got error QUERY expression:")
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return values, unmarshalErr
+}
+
+func (s *DynamoOrderRepository) put(order Order, condition expression.ConditionBuilder) error {
+	// Events are written in the same item as the change that raised them
+	order.OutboxPending = ""
+	if len(order.Outbox) > 0 {
+		order.OutboxPending = outboxPending
+	}
+
+	av, err := dynamodbattribute.MarshalMap(order)
+	if err != nil {
+		log.Println("Got error calling dynamodbattribute MarshalMap:")
+		log.Println(err.Error())
+		return err
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:                      av,
+		TableName:                 aws.String(s.tableName),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	_, err = s.client.PutItem(input)
+	if err != nil {
+		log.Println("Got error calling PutItem:")
+		log.Println(err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,184 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	guuuid "github.com/google/uuid"
+)
+
+// Order event types
+const (
+	EventTypeOrderCreated       = "OrderCreated"
+	EventTypeOrderStatusChanged = "OrderStatusChanged"
+	EventTypeOrderCancelled     = "OrderCancelled"
+)
+
+// OrderEvent Struct - published for downstream consumers such as event
+// tracking, analytics and SMS notifications. Events are delivered at least
+// once, so consumers should ignore IDs they have already seen.
+type OrderEvent struct {
+	ID             string     `json:"id"`
+	EventType      string     `json:"event_type"`
+	Timestamp      time.Time  `json:"timestamp"`
+	OrderID        string     `json:"order_id"`
+	Username       string     `json:"username"`
+	Status         string     `json:"status"`
+	PreviousStatus string     `json:"previous_status,omitempty"`
+	Actor          string     `json:"actor,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	DeliveryType   string     `json:"delivery_type"`
+	Channel        string     `json:"channel,omitempty"`
+	Items          OrderItems `json:"items"`
+	Total          float32    `json:"total"`
+}
+
+// recordStatusEvents adds the events for a change of status to the order's
+// outbox. They are stored with the order and published by the outbox relay
+// once the change has been saved, so they are only sent for changes that
+// happened. The order ID is filled in when the event is published, since a
+// new order doesn't have one yet.
+func (o *Order) recordStatusEvents(change StatusChange) {
+	eventTypes := []string{EventTypeOrderStatusChanged}
+	if len(change.From) == 0 {
+		eventTypes = []string{EventTypeOrderCreated}
+	} else if change.To == StatusCancelled {
+		eventTypes = append(eventTypes, EventTypeOrderCancelled)
+	}
+
+	for _, eventType := range eventTypes {
+		o.Outbox = append(o.Outbox, OrderEvent{
+			ID:             guuuid.New().String(),
+			EventType:      eventType,
+			Timestamp:      change.Timestamp,
+			Username:       o.Username,
+			Status:         change.To,
+			PreviousStatus: change.From,
+			Actor:          change.Actor,
+			Reason:         change.Reason,
+			DeliveryType:   o.DeliveryType,
+			Channel:        o.Channel,
+			Items:          o.Items,
+			Total:          o.Total,
+		})
+	}
+}
+
+// EventPublisher delivers order events. Implementations must be safe for
+// concurrent use.
+type EventPublisher interface {
+	// Publish delivers the events in order. If it returns an error none, some
+	// or all of the events may have been delivered.
+	Publish(events []OrderEvent) error
+}
+
+// NewEventPublisher returns the publisher selected by ORDER_EVENTS_PUBLISHER:
+// "memory" (default), "file", which appends to ORDER_EVENTS_FILE, or
+// "kinesis", which puts records on the ORDER_EVENTS_STREAM stream.
+func NewEventPublisher() EventPublisher {
+	publisher := strings.ToLower(getEnvDefault("ORDER_EVENTS_PUBLISHER", "memory"))
+
+	switch publisher {
+	case "file":
+		path := getEnvDefault("ORDER_EVENTS_FILE", "order-events.ndjson")
+		log.Println("Publishing order events to file: ", path)
+		return NewFileEventPublisher(path)
+	case "kinesis":
+		stream := os.Getenv("ORDER_EVENTS_STREAM")
+		log.Println("Publishing order events to Kinesis stream: ", stream)
+		return NewKinesisEventPublisher(stream)
+	case "memory":
+	default:
+		log.Println("Unknown ORDER_EVENTS_PUBLISHER; keeping order events in memory: ", publisher)
+	}
+
+	return NewMemoryEventPublisher(1000)
+}
+
+// MemoryEventPublisher keeps the most recent events in memory, for local
+// development where nothing consumes them
+type MemoryEventPublisher struct {
+	mu     sync.Mutex
+	limit  int
+	events []OrderEvent
+}
+
+// NewMemoryEventPublisher Function
+func NewMemoryEventPublisher(limit int) *MemoryEventPublisher {
+	return &MemoryEventPublisher{limit: limit}
+}
+
+// Publish Function
+func (p *MemoryEventPublisher) Publish(events []OrderEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+	if len(p.events) > p.limit {
+		p.events = append([]OrderEvent{}, p.events[len(p.events)-p.limit:]...)
+	}
+	return nil
+}
+
+// Events returns the events kept, oldest first
+func (p *MemoryEventPublisher) Events() []OrderEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]OrderEvent{}, p.events...)
+}
+
+// FileEventPublisher appends each event as a line of JSON to a file. The file
+// is opened for every batch so it can be rotated or removed while running.
+type FileEventPublisher struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileEventPublisher Function
+func NewFileEventPublisher(path string) *FileEventPublisher {
+	return &FileEventPublisher{path: path}
+}
+
+// Publish Function
+func (p *FileEventPublisher) Publish(events []OrderEvent) error {
+	var data []byte
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// getEnvDefault returns the value of an environment variable or def when it
+// is unset or empty
+func getEnvDefault(key string, def string) string {
+	if value := os.Getenv(key); len(value) > 0 {
+		return value
+	}
+	return def
+}
@@ -2,4 +2,11 @@ module orders
 
 go 1.11
 
-require github.com/gorilla/mux v1.8.0
+require (
+	github.com/aws/aws-sdk-go v1.44.97
+	github.com/google/uuid v1.1.5
+	github.com/gorilla/mux v1.8.0
+	shared v0.0.0
+)
+
+replace shared => ../../../shared
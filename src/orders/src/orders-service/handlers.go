@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 )
@@ -23,10 +25,36 @@ func OrderIndex(w http.ResponseWriter, r *http.Request) {
 
 	enableCors(&w)
 
+	// Without parameters, list every order as before paging was added
+	if len(r.URL.Query()) == 0 {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(RepoFindAllOrders()); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	query, err := ParseOrderQuery(r.URL.Query())
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	page, err := RepoQueryOrders(query)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if len(page.NextCursor) > 0 {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
 	w.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(orders); err != nil {
+	if err := json.NewEncoder(w).Encode(page.Orders); err != nil {
 		panic(err)
 	}
 }
@@ -79,9 +107,17 @@ func OrderUpdate(w http.ResponseWriter, r *http.Request) {
 		if err := json.NewEncoder(w).Encode(err); err != nil {
 			panic(err)
 		}
+		return
 	}
 
-	t := RepoUpdateOrder(order)
+	vars := mux.Vars(r)
+	order.ID = vars["orderID"]
+
+	t, err := RepoUpdateOrder(order)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(t); err != nil {
@@ -89,15 +125,35 @@ func OrderUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-//OrderCreate Func
-func OrderCreate(w http.ResponseWriter, r *http.Request) {
+// OrderTransitionIndex Handler
+func OrderTransitionIndex(w http.ResponseWriter, r *http.Request) {
+
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	order := RepoFindOrderByID(vars["orderID"])
+	if len(order.ID) == 0 {
+		writeOrderError(w, ErrOrderNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(order.History); err != nil {
+		panic(err)
+	}
+}
+
+// OrderTransitionCreate Func
+func OrderTransitionCreate(w http.ResponseWriter, r *http.Request) {
 	enableCors(&w)
 	if (*r).Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	var order Order
+	var request TransitionRequest
 	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
 	if err != nil {
 		panic(err)
@@ -105,15 +161,98 @@ func OrderCreate(w http.ResponseWriter, r *http.Request) {
 	if err := r.Body.Close(); err != nil {
 		panic(err)
 	}
-	if err := json.Unmarshal(body, &order); err != nil {
+	if err := json.Unmarshal(body, &request); err != nil {
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 		w.WriteHeader(422) // unprocessable entity
 		if err := json.NewEncoder(w).Encode(err); err != nil {
 			panic(err)
 		}
+		return
 	}
 
-	t := RepoCreateOrder(order)
+	vars := mux.Vars(r)
+
+	t, err := RepoTransitionOrder(vars["orderID"], request)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(t); err != nil {
+		panic(err)
+	}
+}
+
+// OrderCancel Handler
+func OrderCancel(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var request CancelRequest
+	if !readJSONBody(w, r, &request) {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	order, err := RepoCancelOrder(vars["orderID"], request)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		panic(err)
+	}
+}
+
+//OrderCreate Func
+func OrderCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var order Order
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		panic(err)
+	}
+	if err := r.Body.Close(); err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(body, &order); err != nil {
+		writeOrderError(w, &ValidationError{Errors: []FieldError{{Field: "body", Message: err.Error()}}})
+		return
+	}
+
+	// Retries that carry the same Idempotency-Key get the original order back
+	var t Order
+	if key := r.Header.Get("Idempotency-Key"); len(key) > 0 {
+		if len(key) > maxIdempotencyKeyLength {
+			writeOrderError(w, &ValidationError{Errors: []FieldError{{Field: "Idempotency-Key", Message: "must be at most " + strconv.Itoa(maxIdempotencyKeyLength) + " characters"}}})
+			return
+		}
+
+		var replayed bool
+		t, replayed, err = RepoCreateOrderOnce(key, body, order)
+		if replayed {
+			w.Header().Set("Idempotent-Replayed", "true")
+		}
+	} else {
+		t, err = RepoCreateOrder(order)
+	}
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(t); err != nil {
@@ -121,9 +260,64 @@ func OrderCreate(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeOrderError maps errors from order operations to HTTP responses
+func writeOrderError(w http.ResponseWriter, err error) {
+	if transitionErr, ok := err.(*TransitionError); ok {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusConflict)
+		if err := json.NewEncoder(w).Encode(transitionErr); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if validationErr, ok := err.(*ValidationError); ok {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(validationErr); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if stockErr, ok := err.(*OutOfStockError); ok {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusConflict)
+		if err := json.NewEncoder(w).Encode(stockErr); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if queryErr, ok := err.(*QueryError); ok {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(queryErr); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	switch err {
+	case ErrOrderNotFound, ErrReturnNotFound, ErrShipmentNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrOrderVersionConflict, ErrIdempotencyKeyReused, ErrIdempotencyKeyInProgress, ErrOrderNotReturnable,
+		ErrOrderNotShippable, ErrShipmentDelivered, ErrOrderNotCollectable, ErrPickupSlotFull, ErrPickupNotBooked:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case ErrUnknownStatus:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case ErrProductsUnavailable, ErrInventoryUnavailable, ErrPromotionsUnavailable:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		log.Println("Order error: ", err)
+		http.Error(w, "Internal error updating order", http.StatusInternalServerError)
+	}
+}
+
 // enableCors
 func enableCors(w *http.ResponseWriter) {
 	(*w).Header().Set("Access-Control-Allow-Origin", "*")
 	(*w).Header().Set("Access-Control-Allow-Methods", "POST, PUT, GET, OPTIONS")
-	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key")
+	(*w).Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor, Idempotent-Replayed")
 }
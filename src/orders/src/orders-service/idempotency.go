@@ -0,0 +1,286 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	guuuid "github.com/google/uuid"
+)
+
+// How long an Idempotency-Key is remembered after the order is created
+var idempotencyWindow = time.Duration(getEnvInt("IDEMPOTENCY_WINDOW_HOURS", 24)) * time.Hour
+
+// How long a request holds its key before another request may take it over,
+// so a key isn't stuck if the instance handling it dies before finishing. The
+// lock is extended every idempotencyLockRenewal while the order is created,
+// however long the calls to other services take.
+const (
+	idempotencyLockTimeout = 30 * time.Second
+	idempotencyLockRenewal = idempotencyLockTimeout / 3
+)
+
+// Longest Idempotency-Key accepted
+const maxIdempotencyKeyLength = 255
+
+// Errors returned for requests that carry an Idempotency-Key
+var (
+	ErrIdempotencyKeyExists     = errors.New("Idempotency key has already been used")
+	ErrIdempotencyKeyReused     = errors.New("Idempotency key has already been used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("A request with this idempotency key is still being processed")
+	ErrIdempotencyLockLost      = errors.New("Idempotency key is no longer held by this request")
+)
+
+// IdempotencyRecord Struct - a request made with an Idempotency-Key and, once
+// it has succeeded, the response returned for it
+type IdempotencyRecord struct {
+	Key         string    `dynamodbav:"idempotency_key"`
+	RequestHash string    `dynamodbav:"request_hash"`
+	OrderID     string    `dynamodbav:"order_id,omitempty"`
+	Response    string    `dynamodbav:"response,omitempty"` // JSON body returned for the request
+	CreatedAt   time.Time `dynamodbav:"created_at"`
+	LockToken   string    `dynamodbav:"lock_token,omitempty"`   // identifies the request holding the lock, cleared when complete
+	LockedUntil int64     `dynamodbav:"locked_until,omitempty"` // epoch seconds, cleared when complete
+	ExpiresAt   int64     `dynamodbav:"expires_at"`             // epoch seconds, the table's TTL attribute
+}
+
+// live reports whether the record still holds its key at now
+func (r IdempotencyRecord) live(now time.Time) bool {
+	if r.ExpiresAt <= now.Unix() {
+		return false
+	}
+	return len(r.Response) > 0 || r.LockedUntil > now.Unix()
+}
+
+// IdempotencyStore remembers Idempotency-Keys. Implementations must be safe
+// for concurrent use and must not let two requests hold the same key. A
+// request that began a record holds its key while the stored record has the
+// same LockToken; the other methods return ErrIdempotencyLockLost once a
+// request whose lock timed out has been replaced by another.
+type IdempotencyStore interface {
+	// Begin stores record unless a live record already holds its key, in which
+	// case the existing record is returned with ErrIdempotencyKeyExists
+	Begin(record IdempotencyRecord) (IdempotencyRecord, error)
+	// Extend moves the lock on key held with lockToken to lockedUntil
+	Extend(key string, lockToken string, lockedUntil int64) error
+	// Complete stores the finished record, releasing its lock
+	Complete(record IdempotencyRecord) error
+	// Release forgets key held with lockToken so the request can be retried
+	Release(key string, lockToken string) error
+}
+
+var idempotencyStore IdempotencyStore
+
+// Init
+func init() {
+	idempotencyStore = NewIdempotencyStore()
+}
+
+// NewIdempotencyStore returns a DynamoDB backed store when an idempotency
+// table is configured, otherwise an in-memory store.
+func NewIdempotencyStore() IdempotencyStore {
+	if len(ddbTableIdempotency) > 0 {
+		log.Println("Using DynamoDB idempotency store with table: ", ddbTableIdempotency)
+		return NewDynamoIdempotencyStore(dynamoClient, ddbTableIdempotency)
+	}
+
+	if len(ddbTableOrders) > 0 {
+		log.Println("DDB_TABLE_IDEMPOTENCY is not set; idempotency keys are only shared by requests to this instance")
+	}
+	log.Println("Using in-memory idempotency store")
+	return NewMemoryIdempotencyStore()
+}
+
+// MemoryIdempotencyStore keeps records in process memory. Expired records are
+// swept out periodically.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]IdempotencyRecord
+	nextSweep time.Time
+}
+
+// NewMemoryIdempotencyStore Function
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+}
+
+// Begin Function
+func (s *MemoryIdempotencyStore) Begin(record IdempotencyRecord) (IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for key, r := range s.records {
+			if r.ExpiresAt <= now.Unix() {
+				delete(s.records, key)
+			}
+		}
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	if existing, ok := s.records[record.Key]; ok && existing.live(now) {
+		return existing, ErrIdempotencyKeyExists
+	}
+
+	s.records[record.Key] = record
+	return record, nil
+}
+
+// Extend Function
+func (s *MemoryIdempotencyStore) Extend(key string, lockToken string, lockedUntil int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.records[key]
+	if !ok || existing.LockToken != lockToken {
+		return ErrIdempotencyLockLost
+	}
+	existing.LockedUntil = lockedUntil
+	s.records[key] = existing
+	return nil
+}
+
+// Complete Function
+func (s *MemoryIdempotencyStore) Complete(record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[record.Key]; !ok || existing.LockToken != record.LockToken {
+		return ErrIdempotencyLockLost
+	}
+	record.LockToken = ""
+	record.LockedUntil = 0
+	s.records[record.Key] = record
+	return nil
+}
+
+// Release Function
+func (s *MemoryIdempotencyStore) Release(key string, lockToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[key]; !ok || existing.LockToken != lockToken {
+		return ErrIdempotencyLockLost
+	}
+	delete(s.records, key)
+	return nil
+}
+
+// RepoCreateOrderOnce Function
+// Creates the order unless a request with the same key has already done so,
+// in which case the order returned for that request is returned again and
+// replayed is true. The key can't be reused for a different request body
+// until the idempotency window has passed. If creating the order fails the
+// key is released so the request can be retried. The key's lock is extended
+// while the order is created, and the response is only recorded, and the key
+// only released, while this request still holds the lock.
+func RepoCreateOrderOnce(key string, body []byte, t Order) (order Order, replayed bool, err error) {
+	now := time.Now().UTC()
+	record := IdempotencyRecord{
+		Key:         key,
+		RequestHash: hashRequest(body),
+		CreatedAt:   now,
+		LockToken:   guuuid.New().String(),
+		LockedUntil: now.Add(idempotencyLockTimeout).Unix(),
+		ExpiresAt:   now.Add(idempotencyWindow).Unix(),
+	}
+
+	existing, err := idempotencyStore.Begin(record)
+	if err == ErrIdempotencyKeyExists {
+		if existing.RequestHash != record.RequestHash {
+			return Order{}, false, ErrIdempotencyKeyReused
+		}
+		if len(existing.Response) == 0 {
+			return Order{}, false, ErrIdempotencyKeyInProgress
+		}
+		if err := json.Unmarshal([]byte(existing.Response), &order); err != nil {
+			log.Println("RepoCreateOrderOnce error reading stored response: ", key, err)
+			return Order{}, false, err
+		}
+		return order, true, nil
+	}
+	if err != nil {
+		log.Println("RepoCreateOrderOnce error: ", err)
+		return Order{}, false, err
+	}
+
+	stop := make(chan struct{})
+	go holdLock(record, stop)
+	order, err = RepoCreateOrder(t)
+	close(stop)
+	if err != nil {
+		if err := idempotencyStore.Release(key, record.LockToken); err != nil {
+			log.Println("RepoCreateOrderOnce unable to release key: ", key, err)
+		}
+		return Order{}, false, err
+	}
+
+	// The order exists at this point, so failing to record the response is only logged
+	response, err := json.Marshal(order)
+	if err == nil {
+		record.OrderID = order.ID
+		record.Response = string(response)
+		err = idempotencyStore.Complete(record)
+	}
+	if err != nil {
+		log.Println("RepoCreateOrderOnce unable to record response: ", key, err)
+	}
+
+	return order, false, nil
+}
+
+// holdLock extends the lock on record's key until stop is closed, so the key
+// isn't taken over while the order is still being created. It gives up once
+// the lock has been lost.
+func holdLock(record IdempotencyRecord, stop <-chan struct{}) {
+	ticker := time.NewTicker(idempotencyLockRenewal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			err := idempotencyStore.Extend(record.Key, record.LockToken, now.Add(idempotencyLockTimeout).Unix())
+			if err == ErrIdempotencyLockLost {
+				log.Println("holdLock lost the lock on key: ", record.Key)
+				return
+			}
+			if err != nil {
+				log.Println("holdLock unable to extend lock: ", record.Key, err)
+			}
+		}
+	}
+}
+
+// hashRequest returns a digest of a request body for comparing retries
+func hashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// getEnvInt returns the integer value of an environment variable or def when
+// it is unset or invalid
+func getEnvInt(key string, def int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || len(value) == 0 {
+		return def
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Println("Invalid value for ", key, "; using default: ", def)
+		return def
+	}
+	return i
+}
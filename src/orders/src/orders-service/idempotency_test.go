@@ -0,0 +1,166 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyRecordLive(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Minute).Unix()
+	earlier := now.Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name   string
+		record IdempotencyRecord
+		want   bool
+	}{
+		{name: "locked", record: IdempotencyRecord{LockedUntil: later, ExpiresAt: later}, want: true},
+		{name: "lock timed out", record: IdempotencyRecord{LockedUntil: earlier, ExpiresAt: later}, want: false},
+		{name: "complete", record: IdempotencyRecord{Response: "{}", ExpiresAt: later}, want: true},
+		{name: "expired", record: IdempotencyRecord{Response: "{}", ExpiresAt: earlier}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.record.live(now); got != tt.want {
+				t.Errorf("live() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateOrderOnce(t *testing.T) {
+	first := []byte(`{"username":"tester"}`)
+	other := []byte(`{"username":"someone else"}`)
+
+	tests := []struct {
+		name         string
+		held         *IdempotencyRecord
+		body         []byte
+		cartID       string
+		outOfStock   bool
+		wantErr      error
+		wantReplayed bool
+		wantCreated  bool
+	}{
+		{name: "new key", body: first, wantCreated: true},
+		{name: "same request again", held: &IdempotencyRecord{RequestHash: hashRequest(first), Response: `{"id":"replayed"}`}, body: first, wantReplayed: true},
+		{name: "different request", held: &IdempotencyRecord{RequestHash: hashRequest(first), Response: `{"id":"replayed"}`}, body: other, wantErr: ErrIdempotencyKeyReused},
+		{name: "still in progress", held: &IdempotencyRecord{RequestHash: hashRequest(first)}, body: first, wantErr: ErrIdempotencyKeyInProgress},
+		{name: "abandoned lock", held: &IdempotencyRecord{RequestHash: hashRequest(first), LockedUntil: time.Now().Add(-time.Second).Unix()}, body: first, wantCreated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, restore := useFakeCatalog()
+			defer restore()
+			store := NewMemoryIdempotencyStore()
+			saved := idempotencyStore
+			idempotencyStore = store
+			defer func() { idempotencyStore = saved }()
+
+			if tt.held != nil {
+				held := *tt.held
+				held.Key = "key"
+				held.ExpiresAt = time.Now().Add(time.Hour).Unix()
+				if held.LockedUntil == 0 && len(held.Response) == 0 {
+					held.LockedUntil = time.Now().Add(time.Minute).Unix()
+				}
+				if _, err := store.Begin(held); err != nil {
+					t.Fatalf("Begin() error = %v", err)
+				}
+			}
+
+			order, replayed, err := RepoCreateOrderOnce("key", tt.body, newTestOrder(""))
+			if err != tt.wantErr {
+				t.Fatalf("RepoCreateOrderOnce() error = %v, want %v", err, tt.wantErr)
+			}
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && order.ID != "replayed" {
+				t.Errorf("replayed order ID = %q, want the stored response", order.ID)
+			}
+			if !tt.wantCreated {
+				return
+			}
+
+			record := store.records["key"]
+			if record.OrderID != order.ID || len(record.Response) == 0 || record.LockedUntil != 0 {
+				t.Errorf("stored record = %+v, want the response for order %s", record, order.ID)
+			}
+			again, replayed, err := RepoCreateOrderOnce("key", tt.body, newTestOrder(""))
+			if err != nil || !replayed || again.ID != order.ID {
+				t.Errorf("retry = %s, %v, %v, want replay of order %s", again.ID, replayed, err, order.ID)
+			}
+		})
+	}
+}
+
+func TestCreateOrderOnceReleasesKeyOnFailure(t *testing.T) {
+	catalog, restore := useFakeCatalog()
+	defer restore()
+	saved := idempotencyStore
+	idempotencyStore = NewMemoryIdempotencyStore()
+	defer func() { idempotencyStore = saved }()
+
+	body := []byte(`{"cart_id":"cart-1"}`)
+	catalog.stock["apple"] = 0
+	if _, _, err := RepoCreateOrderOnce("key", body, newTestOrder("cart-1")); err == nil {
+		t.Fatalf("RepoCreateOrderOnce() succeeded without stock")
+	}
+
+	catalog.stock["apple"] = 10
+	order, replayed, err := RepoCreateOrderOnce("key", body, newTestOrder("cart-1"))
+	if err != nil || replayed || len(order.ID) == 0 {
+		t.Errorf("retry = %s, %v, %v, want a new order", order.ID, replayed, err)
+	}
+}
+
+func TestIdempotencyLockOwner(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(store *MemoryIdempotencyStore, held IdempotencyRecord) error
+	}{
+		{name: "extend", call: func(store *MemoryIdempotencyStore, held IdempotencyRecord) error {
+			return store.Extend(held.Key, held.LockToken, time.Now().Add(time.Minute).Unix())
+		}},
+		{name: "complete", call: func(store *MemoryIdempotencyStore, held IdempotencyRecord) error {
+			held.Response = `{"id":"late"}`
+			return store.Complete(held)
+		}},
+		{name: "release", call: func(store *MemoryIdempotencyStore, held IdempotencyRecord) error {
+			return store.Release(held.Key, held.LockToken)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryIdempotencyStore()
+			expires := time.Now().Add(time.Hour).Unix()
+
+			// The first request's lock times out and a retry takes the key over
+			first := IdempotencyRecord{Key: "key", LockToken: "first", LockedUntil: time.Now().Add(-time.Second).Unix(), ExpiresAt: expires}
+			second := IdempotencyRecord{Key: "key", LockToken: "second", LockedUntil: time.Now().Add(time.Minute).Unix(), ExpiresAt: expires}
+			for _, record := range []IdempotencyRecord{first, second} {
+				if _, err := store.Begin(record); err != nil {
+					t.Fatalf("Begin(%s) error = %v", record.LockToken, err)
+				}
+			}
+
+			if err := tt.call(store, first); err != ErrIdempotencyLockLost {
+				t.Errorf("first request error = %v, want %v", err, ErrIdempotencyLockLost)
+			}
+			if stored := store.records["key"]; stored != second {
+				t.Errorf("stored record = %+v, want the second request's %+v", stored, second)
+			}
+			if err := tt.call(store, second); err != nil {
+				t.Errorf("second request error = %v", err)
+			}
+		})
+	}
+}
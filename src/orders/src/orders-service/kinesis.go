@@ -0,0 +1,74 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+)
+
+// Maximum number of records Kinesis accepts in one PutRecords call
+const maxKinesisRecords = 500
+
+// KinesisEventPublisher puts events on a Kinesis data stream. Events are
+// partitioned by order ID so each order's events are read in order.
+type KinesisEventPublisher struct {
+	client     *kinesis.Kinesis
+	streamName string
+}
+
+// NewKinesisEventPublisher Function
+func NewKinesisEventPublisher(streamName string) *KinesisEventPublisher {
+	return &KinesisEventPublisher{client: kinesis.New(sess), streamName: streamName}
+}
+
+// Publish Function
+func (p *KinesisEventPublisher) Publish(events []OrderEvent) error {
+	for start := 0; start < len(events); start += maxKinesisRecords {
+		end := start + maxKinesisRecords
+		if end > len(events) {
+			end = len(events)
+		}
+
+		if err := p.putRecords(events[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *KinesisEventPublisher) putRecords(events []OrderEvent) error {
+	records := make([]*kinesis.PutRecordsRequestEntry, len(events))
+	for i, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		records[i] = &kinesis.PutRecordsRequestEntry{
+			Data:         data,
+			PartitionKey: aws.String(event.OrderID),
+		}
+	}
+
+	output, err := p.client.PutRecords(&kinesis.PutRecordsInput{
+		Records:    records,
+		StreamName: aws.String(p.streamName),
+	})
+	if err != nil {
+		log.Println("Got error calling PutRecords:")
+		log.Println(err.Error())
+		return err
+	}
+
+	// Records can fail individually; the whole batch is retried since
+	// consumers ignore events they have already seen
+	if aws.Int64Value(output.FailedRecordCount) > 0 {
+		return fmt.Errorf("%d of %d records could not be put on stream %s", aws.Int64Value(output.FailedRecordCount), len(records), p.streamName)
+	}
+	return nil
+}
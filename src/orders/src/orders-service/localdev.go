@@ -0,0 +1,261 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+/*
+ * Supports developing locally where DDB is running locally using
+ * amazon/dynamodb-local (Docker) or local DynamoDB.
+ * https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/DynamoDBLocal.html
+ */
+
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"shared/promotions"
+)
+
+func init() {
+	if runningLocal {
+		waitForLocalDDB()
+		if len(ddbTableOrders) > 0 {
+			if err := createOrdersTable(); err != nil {
+				log.Panic("Unable to create orders table.")
+			}
+		}
+		if len(ddbTableIdempotency) > 0 {
+			if err := createIdempotencyTable(); err != nil {
+				log.Panic("Unable to create idempotency table.")
+			}
+		}
+		if len(ddbTablePickupSlots) > 0 {
+			if err := createPickupSlotsTable(); err != nil {
+				log.Panic("Unable to create pickup slots table.")
+			}
+		}
+		if len(ddbTablePromotionUsage) > 0 {
+			if err := promotions.CreateUsageTable(dynamoClient, ddbTablePromotionUsage); err != nil {
+				log.Panic("Unable to create promotion usage table.")
+			}
+		}
+	}
+}
+
+// waitForLocalDDB - since local DDB can take a couple seconds to startup, we give it some time.
+func waitForLocalDDB() {
+	log.Println("Verifying that local DynamoDB is running at: ", ddbEndpointOverride)
+
+	ddbRunning := false
+
+	for i := 0; i < 5; i++ {
+		resp, _ := http.Get(ddbEndpointOverride)
+
+		if resp != nil && resp.StatusCode >= 200 {
+			log.Println("Received HTTP response from local DynamoDB service!")
+			ddbRunning = true
+			break
+		}
+
+		log.Println("Local DynamoDB service is not ready yet... pausing before trying again")
+		time.Sleep(2 * time.Second)
+	}
+
+	if !ddbRunning {
+		log.Panic("Local DynamoDB service not responding; verify that your docker-compose .env file is setup correctly")
+	}
+}
+
+func createOrdersTable() error {
+	log.Println("Creating orders table: ", ddbTableOrders)
+
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("username"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("status"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("outbox_pending"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("created_at"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("username-created-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{
+						AttributeName: aws.String("username"),
+						KeyType:       aws.String("HASH"),
+					},
+					{
+						AttributeName: aws.String("created_at"),
+						KeyType:       aws.String("RANGE"),
+					},
+				},
+				Projection: &dynamodb.Projection{
+					ProjectionType: aws.String("ALL"),
+				},
+			},
+			{
+				IndexName: aws.String("status-created-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{
+						AttributeName: aws.String("status"),
+						KeyType:       aws.String("HASH"),
+					},
+					{
+						AttributeName: aws.String("created_at"),
+						KeyType:       aws.String("RANGE"),
+					},
+				},
+				Projection: &dynamodb.Projection{
+					ProjectionType: aws.String("ALL"),
+				},
+			},
+			{
+				IndexName: aws.String("outbox-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{
+						AttributeName: aws.String("outbox_pending"),
+						KeyType:       aws.String("HASH"),
+					},
+				},
+				Projection: &dynamodb.Projection{
+					ProjectionType: aws.String("ALL"),
+				},
+			},
+		},
+		TableName: aws.String(ddbTableOrders),
+	}
+
+	_, err := dynamoClient.CreateTable(input)
+	if err != nil {
+		log.Println("Error creating orders table: ", ddbTableOrders)
+
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() == dynamodb.ErrCodeResourceInUseException {
+				log.Println("Table already exists; continuing")
+				err = nil
+			} else {
+				log.Println(err.Error())
+			}
+		} else {
+			log.Println(err.Error())
+		}
+	}
+
+	return err
+}
+
+func createIdempotencyTable() error {
+	log.Println("Creating idempotency table: ", ddbTableIdempotency)
+
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("idempotency_key"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("idempotency_key"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		TableName:   aws.String(ddbTableIdempotency),
+	}
+
+	_, err := dynamoClient.CreateTable(input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceInUseException {
+			log.Println("Table already exists; continuing")
+			return nil
+		}
+		log.Println("Error creating idempotency table: ", ddbTableIdempotency)
+		log.Println(err.Error())
+		return err
+	}
+
+	_, err = dynamoClient.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(ddbTableIdempotency),
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String("expires_at"),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		// Expired records are still ignored without TTL; they just aren't deleted
+		log.Println("Unable to enable TTL on idempotency table: ", err)
+	}
+
+	return nil
+}
+
+func createPickupSlotsTable() error {
+	log.Println("Creating pickup slots table: ", ddbTablePickupSlots)
+
+	input := &dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("store_id"),
+				AttributeType: aws.String("S"),
+			},
+			{
+				AttributeName: aws.String("slot_start"),
+				AttributeType: aws.String("N"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("store_id"),
+				KeyType:       aws.String("HASH"),
+			},
+			{
+				AttributeName: aws.String("slot_start"),
+				KeyType:       aws.String("RANGE"),
+			},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		TableName:   aws.String(ddbTablePickupSlots),
+	}
+
+	_, err := dynamoClient.CreateTable(input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceInUseException {
+			log.Println("Table already exists; continuing")
+			return nil
+		}
+		log.Println("Error creating pickup slots table: ", ddbTablePickupSlots)
+		log.Println(err.Error())
+		return err
+	}
+
+	return nil
+}
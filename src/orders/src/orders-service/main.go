@@ -9,6 +9,8 @@ import (
 )
 
 func main() {
+	outboxRelay.Start()
+
 	router := NewRouter()
 	log.Fatal(http.ListenAndServe(":80", router))
 }
@@ -0,0 +1,252 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// MemoryOrderRepository keeps orders in process memory, indexed by username,
+// status and unpublished events. Orders are lost on restart.
+type MemoryOrderRepository struct {
+	mu         sync.RWMutex
+	currentID  int
+	orders     map[string]Order
+	byUsername map[string]map[string]bool
+	byStatus   map[string]map[string]bool
+	pending    map[string]bool
+}
+
+// NewMemoryOrderRepository Function
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		orders:     map[string]Order{},
+		byUsername: map[string]map[string]bool{},
+		byStatus:   map[string]map[string]bool{},
+		pending:    map[string]bool{},
+	}
+}
+
+// FindAll Function
+func (s *MemoryOrderRepository) FindAll() (Orders, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	values := make(Orders, 0, len(s.orders))
+	for _, order := range s.orders {
+		values = append(values, copyOrder(order))
+	}
+	sortOrders(values)
+	return values, nil
+}
+
+// FindByID Function
+func (s *MemoryOrderRepository) FindByID(id string) (Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return Order{}, ErrOrderNotFound
+	}
+	return copyOrder(order), nil
+}
+
+// FindByUsername Function
+func (s *MemoryOrderRepository) FindByUsername(username string) (Orders, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.collect(s.byUsername[username]), nil
+}
+
+// FindByStatus Function
+func (s *MemoryOrderRepository) FindByStatus(status string) (Orders, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.collect(s.byStatus[status]), nil
+}
+
+// Query Function
+func (s *MemoryOrderRepository) Query(q OrderQuery) (OrderPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var values Orders
+	switch q.index() {
+	case "username":
+		values = s.collect(s.byUsername[q.Username])
+	case "status":
+		values = s.collect(s.byStatus[q.Status])
+	default:
+		values = make(Orders, 0, len(s.orders))
+		for _, order := range s.orders {
+			values = append(values, copyOrder(order))
+		}
+	}
+	return q.Page(values)
+}
+
+// FindWithPendingEvents Function
+func (s *MemoryOrderRepository) FindWithPendingEvents() (Orders, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.collect(s.pending), nil
+}
+
+// Create Function
+func (s *MemoryOrderRepository) Create(order Order) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.currentID++
+	order.ID = strconv.Itoa(s.currentID)
+	order.Version = 1
+	s.orders[order.ID] = copyOrder(order)
+	s.index(order)
+	return order, nil
+}
+
+// Update Function
+func (s *MemoryOrderRepository) Update(order Order) (Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.orders[order.ID]
+	if !ok {
+		return Order{}, ErrOrderNotFound
+	}
+	if existing.Version != order.Version {
+		return Order{}, ErrOrderVersionConflict
+	}
+
+	order.Version++
+	s.unindex(existing)
+	s.orders[order.ID] = copyOrder(order)
+	s.index(order)
+	return order, nil
+}
+
+// collect returns copies of the orders with the given IDs. Callers must hold
+// the lock.
+func (s *MemoryOrderRepository) collect(ids map[string]bool) Orders {
+	values := make(Orders, 0, len(ids))
+	for id := range ids {
+		values = append(values, copyOrder(s.orders[id]))
+	}
+	sortOrders(values)
+	return values
+}
+
+// index adds the order to the username, status and pending event indexes.
+// Callers must hold the lock.
+func (s *MemoryOrderRepository) index(order Order) {
+	addToIndex(s.byUsername, order.Username, order.ID)
+	addToIndex(s.byStatus, order.Status, order.ID)
+	if len(order.Outbox) > 0 {
+		s.pending[order.ID] = true
+	}
+}
+
+// unindex removes the order from the indexes. Callers must hold the lock.
+func (s *MemoryOrderRepository) unindex(order Order) {
+	removeFromIndex(s.byUsername, order.Username, order.ID)
+	removeFromIndex(s.byStatus, order.Status, order.ID)
+	delete(s.pending, order.ID)
+}
+
+func addToIndex(index map[string]map[string]bool, key string, id string) {
+	if index[key] == nil {
+		index[key] = map[string]bool{}
+	}
+	index[key][id] = true
+}
+
+func removeFromIndex(index map[string]map[string]bool, key string, id string) {
+	ids := index[key]
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(index, key)
+	}
+}
+
+// sortOrders puts orders in the order they were created. Numeric IDs are
+// compared as numbers so "10" sorts after "9".
+func sortOrders(orders Orders) {
+	sort.Slice(orders, func(i, j int) bool { return idLess(orders[i].ID, orders[j].ID) })
+}
+
+// copyOrder returns an order that does not share its slices with o, so callers
+// can't modify stored orders without going through the repository.
+func copyOrder(o Order) Order {
+	if o.Items != nil {
+		items := make(OrderItems, len(o.Items))
+		copy(items, o.Items)
+		o.Items = items
+	}
+	if o.PromotionCodes != nil {
+		codes := make([]string, len(o.PromotionCodes))
+		copy(codes, o.PromotionCodes)
+		o.PromotionCodes = codes
+	}
+	if o.TaxLines != nil {
+		lines := make([]TaxLine, len(o.TaxLines))
+		copy(lines, o.TaxLines)
+		o.TaxLines = lines
+	}
+	if o.History != nil {
+		history := make([]StatusChange, len(o.History))
+		copy(history, o.History)
+		o.History = history
+	}
+	if o.Returns != nil {
+		returns := make([]Return, len(o.Returns))
+		for i, r := range o.Returns {
+			r.Items = append(ReturnItems{}, r.Items...)
+			r.History = append([]StatusChange{}, r.History...)
+			returns[i] = r
+		}
+		o.Returns = returns
+	}
+	if o.Shipments != nil {
+		shipments := make([]Shipment, len(o.Shipments))
+		for i, sh := range o.Shipments {
+			sh.Items = append(ShipmentItems{}, sh.Items...)
+			sh.Events = append([]TrackingEvent{}, sh.Events...)
+			shipments[i] = sh
+		}
+		o.Shipments = shipments
+	}
+	if o.Refunds != nil {
+		refunds := make([]Refund, len(o.Refunds))
+		copy(refunds, o.Refunds)
+		o.Refunds = refunds
+	}
+	if o.Cancellation != nil {
+		cancellation := *o.Cancellation
+		o.Cancellation = &cancellation
+	}
+	if o.Shipping != nil {
+		shipping := *o.Shipping
+		o.Shipping = &shipping
+	}
+	if o.Pickup != nil {
+		pickup := *o.Pickup
+		if pickup.ArrivedAt != nil {
+			arrived := *pickup.ArrivedAt
+			pickup.ArrivedAt = &arrived
+		}
+		o.Pickup = &pickup
+	}
+	if o.Outbox != nil {
+		outbox := make([]OrderEvent, len(o.Outbox))
+		copy(outbox, o.Outbox)
+		o.Outbox = outbox
+	}
+	return o
+}
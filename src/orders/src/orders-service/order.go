@@ -3,20 +3,48 @@
 
 package main
 
+import (
+	"time"
+
+	"shared/address"
+)
+
 // Order Struct
 type Order struct {
 	ID              string    		`json:"id" yaml:"id"`
-	Username        string     		`json:"username" yaml:"username"`
+	Username        string     		`json:"username" yaml:"username" dynamodbav:"username,omitempty"` // omitted when empty so it can key an index
 	Items           OrderItems 		`json:"items" yaml:"items"`
+	Subtotal        float32         `json:"subtotal" yaml:"subtotal"`
+	Discount        float32         `json:"discount" yaml:"discount"`
+	Tax             float32         `json:"tax" yaml:"tax"`
+	TaxLines        []TaxLine       `json:"tax_lines" yaml:"tax_lines"`
 	Total           float32    		`json:"total" yaml:"total"`
+	PromotionCodes  []string        `json:"promotion_codes" yaml:"promotion_codes"`
+	FreeShipping    bool            `json:"free_shipping" yaml:"free_shipping"`
+	Shipping        *ShippingSelection `json:"shipping,omitempty" yaml:"shipping,omitempty"`
 	BillingAddress  Address    		`json:"billing_address" yaml:"billing_address"`
 	ShippingAddress Address    		`json:"shipping_address" yaml:"shipping_address"`
 	CollectionPhone string          `json:"collection_phone" yaml:"collection_phone"`
 	DeliveryType    string          `json:"delivery_type" yaml:"delivery_type"`
 	DeliveryStatus  string          `json:"delivery_status" yaml:"delivery_status"`
 	DeliveryComplete bool           `json:"delivery_complete" yaml:"delivery_complete"`
+	Status          string          `json:"status" yaml:"status"`
+	History         []StatusChange  `json:"history" yaml:"history"`
+	CreatedAt       time.Time       `json:"created_at" yaml:"created_at"`
+	Cancellation    *Cancellation   `json:"cancellation,omitempty" yaml:"cancellation,omitempty"`
+	CartID          string          `json:"cart_id" yaml:"cart_id"` // the cart checked out, whose stock reservations the order commits
+	StockTaken      bool            `json:"stock_taken" yaml:"stock_taken"`
+	Returns         []Return        `json:"returns" yaml:"returns"`
+	Refunds         []Refund        `json:"refunds" yaml:"refunds"`
+	RefundTotal     float32         `json:"refund_total" yaml:"refund_total"`
+	Shipments       []Shipment      `json:"shipments" yaml:"shipments"`
+	FulfillmentStatus string        `json:"fulfillment_status" yaml:"fulfillment_status"`
+	Pickup          *Pickup         `json:"pickup,omitempty" yaml:"pickup,omitempty"`
 	Channel			string	   		`json:"channel" yaml:"channel"`
 	ChannelDetail   ChannelDetail	`json:"channel_detail" yaml:"channel_detail"`
+	Version         int             `json:"version" yaml:"version"`
+	Outbox          []OrderEvent    `json:"-" yaml:"-" dynamodbav:"outbox,omitempty"` // events waiting to be published
+	OutboxPending   string          `json:"-" yaml:"-" dynamodbav:"outbox_pending,omitempty"` // set while Outbox is not empty so it can key a sparse index
 }
 
 // Orders Array
@@ -28,23 +56,15 @@ type OrderItem struct {
 	ProductName string  `json:"product_name" yaml:"product_name"`
 	Quantity    int     `json:"quantity" yaml:"quantity"`
 	Price       float32 `json:"price" yaml:"price"`
+	Discount    float32 `json:"discount" yaml:"discount"` // total discount for the line
+	Tax         float32 `json:"tax" yaml:"tax"`           // total tax for the line
 }
 
 // OrderItems Array
 type OrderItems []OrderItem
 
 // Address Struct
-type Address struct {
-	FirstName string `json:"first_name" yaml:"first_name"`
-	LastName  string `json:"last_name" yaml:"last_name"`
-	Address1  string `json:"address1" yaml:"address1"`
-	Address2  string `json:"address2" yaml:"address2"`
-	Country   string `json:"country" yaml:"country"`
-	City      string `json:"city" yaml:"city"`
-	State     string `json:"state" yaml:"state"`
-	ZipCode   string `json:"zipcode" yaml:"zipcode"`
-	Default   bool   `json:"default" yaml:"default"`
-}
+type Address = address.Address
 
 // ChannelDetail Struct
 type ChannelDetail struct {
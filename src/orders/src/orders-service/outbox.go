@@ -0,0 +1,101 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// How often the relay looks for unpublished events when it hasn't been
+// notified, e.g. after a publish failed or another instance stored the order
+var outboxPollInterval = time.Duration(getEnvInt("ORDER_EVENTS_POLL_SECONDS", 5)) * time.Second
+
+// Value of Order.OutboxPending while an order has unpublished events
+const outboxPending = "1"
+
+var outboxRelay = NewOutboxRelay(NewEventPublisher())
+
+// OutboxRelay publishes the events stored in order outboxes and removes them
+// once they have been delivered. An event can be published more than once if
+// removing it fails, so delivery is at least once.
+type OutboxRelay struct {
+	publisher EventPublisher
+	wake      chan struct{}
+}
+
+// NewOutboxRelay Function
+func NewOutboxRelay(publisher EventPublisher) *OutboxRelay {
+	return &OutboxRelay{publisher: publisher, wake: make(chan struct{}, 1)}
+}
+
+// Start publishes pending events in the background until the process exits
+func (r *OutboxRelay) Start() {
+	go r.run()
+}
+
+// Notify tells the relay that new events have been stored
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *OutboxRelay) run() {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.flush()
+		select {
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush publishes the events of every order with a non-empty outbox. Orders
+// are published one at a time so their events stay in order.
+func (r *OutboxRelay) flush() {
+	orders, err := orderRepository.FindWithPendingEvents()
+	if err != nil {
+		log.Println("OutboxRelay unable to find pending events: ", err)
+		return
+	}
+
+	for _, order := range orders {
+		if err := r.publish(order); err != nil {
+			log.Println("OutboxRelay unable to publish events for order: ", order.ID, err)
+		}
+	}
+}
+
+// publish delivers the order's pending events and then removes them from its
+// outbox. Events added while publishing are left for the next flush.
+func (r *OutboxRelay) publish(order Order) error {
+	events := make([]OrderEvent, len(order.Outbox))
+	published := map[string]bool{}
+	for i, event := range order.Outbox {
+		event.OrderID = order.ID
+		events[i] = event
+		published[event.ID] = true
+	}
+
+	if err := r.publisher.Publish(events); err != nil {
+		return err
+	}
+
+	_, err := RepoMutateOrder(order.ID, func(o *Order) error {
+		var remaining []OrderEvent
+		for _, event := range o.Outbox {
+			if !published[event.ID] {
+				remaining = append(remaining, event)
+			}
+		}
+		o.Outbox = remaining
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,203 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pickup slots are offered every pickupSlotLength from pickupOpenHour to
+// pickupCloseHour (UTC) each day, up to pickupDaysAhead days ahead, and each
+// slot takes at most pickupSlotCapacity orders per store
+var (
+	pickupOpenHour     = getEnvInt("PICKUP_OPEN_HOUR", 9)
+	pickupCloseHour    = getEnvInt("PICKUP_CLOSE_HOUR", 21)
+	pickupSlotLength   = time.Duration(getEnvInt("PICKUP_SLOT_MINUTES", 30)) * time.Minute
+	pickupSlotCapacity = getEnvInt("PICKUP_SLOT_CAPACITY", 5)
+	pickupDaysAhead    = getEnvInt("PICKUP_DAYS_AHEAD", 7)
+)
+
+// Errors returned by pickup operations
+var (
+	ErrOrderNotCollectable = errors.New("Pickup is only available for collection orders that have not been collected or cancelled")
+	ErrPickupSlotFull      = errors.New("Pickup slot is fully booked")
+	ErrPickupNotBooked     = errors.New("Order has no pickup slot booked")
+)
+
+// Pickup Struct - the slot a collection order is booked to be picked up in and,
+// once the customer has checked in, when they arrived and where they are waiting
+type Pickup struct {
+	StoreID   string     `json:"store_id" yaml:"store_id"`
+	SlotStart time.Time  `json:"slot_start" yaml:"slot_start"`
+	SlotEnd   time.Time  `json:"slot_end" yaml:"slot_end"`
+	ArrivedAt *time.Time `json:"arrived_at,omitempty" yaml:"arrived_at,omitempty"`
+	Bay       int        `json:"bay,omitempty" yaml:"bay,omitempty"`
+}
+
+// PickupSlot Struct - a time slot at a store and how much of it is booked
+type PickupSlot struct {
+	StoreID   string    `json:"store_id" yaml:"store_id"`
+	Start     time.Time `json:"start" yaml:"start"`
+	End       time.Time `json:"end" yaml:"end"`
+	Capacity  int       `json:"capacity" yaml:"capacity"`
+	Booked    int       `json:"booked" yaml:"booked"`
+	Available int       `json:"available" yaml:"available"`
+}
+
+// PickupRequest Struct - request body for booking a pickup slot
+type PickupRequest struct {
+	StoreID   string    `json:"store_id" yaml:"store_id"`
+	SlotStart time.Time `json:"slot_start" yaml:"slot_start"`
+}
+
+// ArrivalRequest Struct - request body for checking in on arrival
+type ArrivalRequest struct {
+	Bay int `json:"bay" yaml:"bay"`
+}
+
+// PickupQueueEntry Struct - a customer waiting at a store
+type PickupQueueEntry struct {
+	OrderID         string    `json:"order_id" yaml:"order_id"`
+	Username        string    `json:"username" yaml:"username"`
+	CollectionPhone string    `json:"collection_phone" yaml:"collection_phone"`
+	Status          string    `json:"status" yaml:"status"`
+	Bay             int       `json:"bay" yaml:"bay"`
+	ArrivedAt       time.Time `json:"arrived_at" yaml:"arrived_at"`
+	SlotStart       time.Time `json:"slot_start" yaml:"slot_start"`
+	SlotEnd         time.Time `json:"slot_end" yaml:"slot_end"`
+}
+
+// pickupSlotStarts returns the start of every slot on date's day that hasn't
+// started by now
+func pickupSlotStarts(date time.Time, now time.Time) []time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	open := day.Add(time.Duration(pickupOpenHour) * time.Hour)
+	closing := day.Add(time.Duration(pickupCloseHour) * time.Hour)
+
+	starts := []time.Time{}
+	if pickupSlotLength <= 0 {
+		return starts
+	}
+	for start := open; !start.Add(pickupSlotLength).After(closing); start = start.Add(pickupSlotLength) {
+		if start.After(now) {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// isPickupSlot reports whether start is the start of a slot that can be booked
+// at now
+func isPickupSlot(start time.Time, now time.Time) bool {
+	start = start.UTC()
+	last := now.UTC().AddDate(0, 0, pickupDaysAhead)
+	if start.After(last) {
+		return false
+	}
+	for _, s := range pickupSlotStarts(start, now) {
+		if s.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectable reports whether the order can still be picked up
+func (o *Order) collectable() bool {
+	return o.DeliveryType == DeliveryTypeCollection && o.Status != StatusDelivered && o.Status != StatusCancelled
+}
+
+// BookPickup checks the request and books the order into the slot, keeping the
+// arrival details if the customer has already checked in at the same store.
+// Capacity is enforced by the caller, which reserves the slot.
+func (o *Order) BookPickup(request PickupRequest, now time.Time) (Pickup, error) {
+	if !o.collectable() {
+		return Pickup{}, ErrOrderNotCollectable
+	}
+
+	storeID := strings.TrimSpace(request.StoreID)
+	if len(storeID) == 0 {
+		// Orders placed in store record the store as the channel's location
+		storeID = strings.TrimSpace(o.ChannelDetail.ChnnelGeo)
+	}
+
+	verr := &ValidationError{}
+	if len(storeID) == 0 {
+		verr.Add("store_id", "is required")
+	}
+	if request.SlotStart.IsZero() {
+		verr.Add("slot_start", "is required")
+	} else if !isPickupSlot(request.SlotStart, now) {
+		verr.Add("slot_start", "is not an available pickup slot")
+	}
+	if err := verr.OrNil(); err != nil {
+		return Pickup{}, err
+	}
+
+	pickup := Pickup{
+		StoreID:   storeID,
+		SlotStart: request.SlotStart.UTC(),
+		SlotEnd:   request.SlotStart.UTC().Add(pickupSlotLength),
+	}
+	if o.Pickup != nil && o.Pickup.StoreID == storeID {
+		pickup.ArrivedAt = o.Pickup.ArrivedAt
+		pickup.Bay = o.Pickup.Bay
+	}
+	o.Pickup = &pickup
+	return pickup, nil
+}
+
+// CheckIn records that the customer has arrived and is waiting in bay.
+// Checking in again moves the customer to another bay but keeps their place
+// in the queue.
+func (o *Order) CheckIn(request ArrivalRequest, now time.Time) (Pickup, error) {
+	if !o.collectable() {
+		return Pickup{}, ErrOrderNotCollectable
+	}
+	if o.Pickup == nil {
+		return Pickup{}, ErrPickupNotBooked
+	}
+	if request.Bay <= 0 {
+		return Pickup{}, &ValidationError{Errors: []FieldError{{Field: "bay", Message: "must be greater than zero"}}}
+	}
+
+	if o.Pickup.ArrivedAt == nil {
+		arrived := now.UTC()
+		o.Pickup.ArrivedAt = &arrived
+	}
+	o.Pickup.Bay = request.Bay
+	return *o.Pickup, nil
+}
+
+// pickupQueue returns the customers waiting at storeID in the order they
+// arrived
+func pickupQueue(orders Orders, storeID string) []PickupQueueEntry {
+	queue := []PickupQueueEntry{}
+	for _, o := range orders {
+		if !o.collectable() || o.Pickup == nil || o.Pickup.StoreID != storeID || o.Pickup.ArrivedAt == nil {
+			continue
+		}
+		queue = append(queue, PickupQueueEntry{
+			OrderID:         o.ID,
+			Username:        o.Username,
+			CollectionPhone: o.CollectionPhone,
+			Status:          o.Status,
+			Bay:             o.Pickup.Bay,
+			ArrivedAt:       *o.Pickup.ArrivedAt,
+			SlotStart:       o.Pickup.SlotStart,
+			SlotEnd:         o.Pickup.SlotEnd,
+		})
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		if !queue[i].ArrivedAt.Equal(queue[j].ArrivedAt) {
+			return queue[i].ArrivedAt.Before(queue[j].ArrivedAt)
+		}
+		return idLess(queue[i].OrderID, queue[j].OrderID)
+	})
+	return queue
+}
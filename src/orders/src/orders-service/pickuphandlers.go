@@ -0,0 +1,118 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// PickupSlotIndex Handler
+// Lists the store's bookable slots on the day given by the "date" query
+// parameter (YYYY-MM-DD), today by default.
+func PickupSlotIndex(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	date := time.Now().UTC()
+	if value := r.URL.Query().Get("date"); len(value) > 0 {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			writeOrderError(w, &QueryError{Parameter: "date", Message: "must be a date in the form YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	slots, err := RepoFindPickupSlots(vars["storeID"], date)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(slots); err != nil {
+		panic(err)
+	}
+}
+
+// PickupQueueIndex Handler
+func PickupQueueIndex(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	queue, err := RepoFindPickupQueue(vars["storeID"])
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(queue); err != nil {
+		panic(err)
+	}
+}
+
+// PickupCreate Handler
+func PickupCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var request PickupRequest
+	if !readJSONBody(w, r, &request) {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	pickup, err := RepoBookPickup(vars["orderID"], request)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(pickup); err != nil {
+		panic(err)
+	}
+}
+
+// ArrivalCreate Handler
+func ArrivalCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var request ArrivalRequest
+	if !readJSONBody(w, r, &request) {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	order, err := RepoCheckInPickup(vars["orderID"], request)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		panic(err)
+	}
+}
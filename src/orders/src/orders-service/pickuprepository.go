@@ -0,0 +1,135 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// RepoFindPickupSlots Function
+// Returns the slots at storeID on date's day that can still be booked, with
+// how many orders each has.
+func RepoFindPickupSlots(storeID string, date time.Time) ([]PickupSlot, error) {
+	slots := []PickupSlot{}
+
+	now := time.Now().UTC()
+	starts := []time.Time{}
+	for _, start := range pickupSlotStarts(date, now) {
+		if isPickupSlot(start, now) {
+			starts = append(starts, start)
+		}
+	}
+	if len(starts) == 0 {
+		return slots, nil
+	}
+
+	booked, err := pickupSlotStore.Booked(storeID, starts[0], starts[len(starts)-1].Add(pickupSlotLength))
+	if err != nil {
+		log.Println("RepoFindPickupSlots error: ", err)
+		return nil, err
+	}
+
+	for _, start := range starts {
+		available := pickupSlotCapacity - booked[start]
+		if available < 0 {
+			available = 0
+		}
+		slots = append(slots, PickupSlot{
+			StoreID:   storeID,
+			Start:     start,
+			End:       start.Add(pickupSlotLength),
+			Capacity:  pickupSlotCapacity,
+			Booked:    booked[start],
+			Available: available,
+		})
+	}
+	return slots, nil
+}
+
+// RepoBookPickup Function
+// Reserves the slot before saving the booking on the order, so a slot can't
+// be overbooked. Moving to another slot gives up the previous one once the
+// order is saved.
+func RepoBookPickup(orderID string, request PickupRequest) (Pickup, error) {
+	order, err := orderRepository.FindByID(orderID)
+	if err != nil {
+		if err != ErrOrderNotFound {
+			log.Println("RepoBookPickup error: ", err)
+		}
+		return Pickup{}, err
+	}
+
+	// Check the request before reserving anything
+	requested, err := order.BookPickup(request, time.Now())
+	if err != nil {
+		return Pickup{}, err
+	}
+
+	if err := pickupSlotStore.Reserve(requested.StoreID, requested.SlotStart, orderID, pickupSlotCapacity); err != nil {
+		if err != ErrPickupSlotFull {
+			log.Println("RepoBookPickup unable to reserve slot: ", err)
+		}
+		return Pickup{}, err
+	}
+
+	var previous *Pickup
+	var booked Pickup
+	_, err = RepoMutateOrder(orderID, func(o *Order) error {
+		previous = o.Pickup
+		p, err := o.BookPickup(request, time.Now())
+		booked = p
+		return err
+	})
+	if err != nil {
+		if previous == nil || !samePickupSlot(*previous, requested) {
+			releasePickupSlot(orderID, &requested)
+		}
+		return Pickup{}, err
+	}
+
+	if previous != nil && !samePickupSlot(*previous, booked) {
+		releasePickupSlot(orderID, previous)
+	}
+	return booked, nil
+}
+
+// RepoCheckInPickup Function
+func RepoCheckInPickup(orderID string, request ArrivalRequest) (Order, error) {
+	return RepoMutateOrder(orderID, func(o *Order) error {
+		_, err := o.CheckIn(request, time.Now())
+		return err
+	})
+}
+
+// RepoFindPickupQueue Function
+// Only open orders can be waiting, so closed ones are never read.
+func RepoFindPickupQueue(storeID string) ([]PickupQueueEntry, error) {
+	waiting := Orders{}
+	for _, status := range []string{StatusPending, StatusPaid, StatusPicking, StatusReadyForCollection} {
+		orders, err := orderRepository.FindByStatus(status)
+		if err != nil {
+			log.Println("RepoFindPickupQueue error: ", err)
+			return nil, err
+		}
+		waiting = append(waiting, orders...)
+	}
+	return pickupQueue(waiting, storeID), nil
+}
+
+// releasePickupSlot gives up the order's slot. Failures are only logged; the
+// slot is left with less capacity than it could have.
+func releasePickupSlot(orderID string, pickup *Pickup) {
+	if pickup == nil {
+		return
+	}
+	if err := pickupSlotStore.Release(pickup.StoreID, pickup.SlotStart, orderID); err != nil {
+		log.Println("releasePickupSlot unable to release slot: ", orderID, pickup.StoreID, pickup.SlotStart, err)
+	}
+}
+
+// samePickupSlot reports whether a and b are bookings for the same slot
+func samePickupSlot(a Pickup, b Pickup) bool {
+	return a.StoreID == b.StoreID && a.SlotStart.Equal(b.SlotStart)
+}
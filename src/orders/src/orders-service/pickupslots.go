@@ -0,0 +1,113 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// PickupSlotStore records which orders are booked into each pickup slot.
+// Implementations must be safe for concurrent use and must never let a slot
+// take more orders than its capacity.
+type PickupSlotStore interface {
+	// Reserve books orderID into the slot at storeID starting at start, or
+	// returns ErrPickupSlotFull. Reserving a slot the order already holds
+	// succeeds.
+	Reserve(storeID string, start time.Time, orderID string, capacity int) error
+	// Release removes orderID from the slot
+	Release(storeID string, start time.Time, orderID string) error
+	// Booked returns the number of orders booked into each of the store's
+	// slots starting from from up to but not including to, keyed by start time
+	Booked(storeID string, from time.Time, to time.Time) (map[time.Time]int, error)
+}
+
+var pickupSlotStore PickupSlotStore
+
+// Init
+func init() {
+	pickupSlotStore = NewPickupSlotStore()
+}
+
+// NewPickupSlotStore returns a DynamoDB backed store when a pickup slots
+// table is configured, otherwise an in-memory store.
+func NewPickupSlotStore() PickupSlotStore {
+	if len(ddbTablePickupSlots) > 0 {
+		log.Println("Using DynamoDB pickup slot store with table: ", ddbTablePickupSlots)
+		return NewDynamoPickupSlotStore(dynamoClient, ddbTablePickupSlots)
+	}
+
+	if len(ddbTableOrders) > 0 {
+		log.Println("DDB_TABLE_PICKUP_SLOTS is not set; pickup slot capacity is only enforced per instance")
+	}
+	log.Println("Using in-memory pickup slot store")
+	return NewMemoryPickupSlotStore()
+}
+
+// pickupSlotKey Struct - identifies a slot at a store
+type pickupSlotKey struct {
+	storeID string
+	start   int64
+}
+
+// MemoryPickupSlotStore keeps bookings in process memory
+type MemoryPickupSlotStore struct {
+	mu    sync.Mutex
+	slots map[pickupSlotKey]map[string]bool
+}
+
+// NewMemoryPickupSlotStore Function
+func NewMemoryPickupSlotStore() *MemoryPickupSlotStore {
+	return &MemoryPickupSlotStore{slots: map[pickupSlotKey]map[string]bool{}}
+}
+
+// Reserve Function
+func (s *MemoryPickupSlotStore) Reserve(storeID string, start time.Time, orderID string, capacity int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pickupSlotKey{storeID, start.Unix()}
+	orders := s.slots[key]
+	if orders[orderID] {
+		return nil
+	}
+	if len(orders) >= capacity {
+		return ErrPickupSlotFull
+	}
+
+	if orders == nil {
+		orders = map[string]bool{}
+		s.slots[key] = orders
+	}
+	orders[orderID] = true
+	return nil
+}
+
+// Release Function
+func (s *MemoryPickupSlotStore) Release(storeID string, start time.Time, orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pickupSlotKey{storeID, start.Unix()}
+	delete(s.slots[key], orderID)
+	if len(s.slots[key]) == 0 {
+		delete(s.slots, key)
+	}
+	return nil
+}
+
+// Booked Function
+func (s *MemoryPickupSlotStore) Booked(storeID string, from time.Time, to time.Time) (map[time.Time]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	booked := map[time.Time]int{}
+	for key, orders := range s.slots {
+		if key.storeID == storeID && key.start >= from.Unix() && key.start < to.Unix() {
+			booked[time.Unix(key.start, 0).UTC()] = len(orders)
+		}
+	}
+	return booked, nil
+}
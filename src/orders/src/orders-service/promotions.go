@@ -0,0 +1,152 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"shared/promotions"
+)
+
+// ErrPromotionsUnavailable is returned when the uses of an order's promotion
+// codes could not be counted
+var ErrPromotionsUnavailable = errors.New("Unable to check promotion code usage")
+
+// Promotions offered by the store, read from the same PROMOTIONS_FILE as the
+// carts service
+var promotionsByCode = indexPromotions(promotions.Configured(os.Getenv("PROMOTIONS_FILE")))
+
+// DynamoDB table name for promotion usage counts, shared with the carts
+// service. When empty, uses are counted in memory.
+var ddbTablePromotionUsage = os.Getenv("DDB_TABLE_PROMOTION_USAGE")
+
+// Uses of each promotion code. Orders redeem their codes when they are
+// created, so usage limits hold however the order is placed.
+var promotionUsage promotions.UsageStore
+
+// Init
+func init() {
+	if len(ddbTablePromotionUsage) > 0 {
+		log.Println("Using DynamoDB promotion usage store with table: ", ddbTablePromotionUsage)
+		promotionUsage = promotions.NewDynamoUsageStore(dynamoClient, ddbTablePromotionUsage)
+	} else {
+		log.Println("Using in-memory promotion usage store")
+		promotionUsage = promotions.NewMemoryUsageStore()
+	}
+}
+
+// findPromotions looks up promotion codes, normalizing them and dropping
+// repeats. Codes that don't exist, aren't valid at now or have reached their
+// usage limit are recorded in verr.
+func findPromotions(verr *ValidationError, codes []string, now time.Time) ([]promotions.Promotion, []string, error) {
+	fields := map[string]string{}
+	found := []promotions.Promotion{}
+	normalized := []string{}
+	seen := map[string]bool{}
+
+	for i, code := range codes {
+		field := "promotion_codes[" + strconv.Itoa(i) + "]"
+		code = promotions.NormalizeCode(code)
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		promotion, ok := promotionsByCode[code]
+		if !ok {
+			verr.Add(field, "is not a promotion code")
+			continue
+		}
+		found = append(found, promotion)
+		fields[code] = field
+	}
+
+	if err := promotions.CountUses(promotionUsage, found); err != nil {
+		log.Println("findPromotions error counting uses: ", err)
+		return nil, nil, ErrPromotionsUnavailable
+	}
+
+	active := []promotions.Promotion{}
+	for _, promotion := range found {
+		switch promotion.Active(now) {
+		case nil:
+			active = append(active, promotion)
+			normalized = append(normalized, promotion.Code)
+		case promotions.ErrUsedUp:
+			verr.Add(fields[promotion.Code], "has reached its usage limit")
+		default:
+			verr.Add(fields[promotion.Code], "is not valid at this time")
+		}
+	}
+	return active, normalized, nil
+}
+
+// redeemPromotions counts a use of each of the order's promotion codes. A code
+// that reached its usage limit since the order was priced fails validation,
+// and the codes already redeemed are reversed.
+func redeemPromotions(codes []string) error {
+	for i, code := range codes {
+		err := promotionUsage.Redeem(code, promotionsByCode[code].UsageLimit)
+		if err == nil {
+			continue
+		}
+
+		unredeemPromotions(codes[:i])
+		if err == promotions.ErrUsedUp {
+			verr := &ValidationError{}
+			verr.Add("promotion_codes["+strconv.Itoa(i)+"]", "has reached its usage limit")
+			return verr
+		}
+		log.Println("redeemPromotions error: ", code, err)
+		return ErrPromotionsUnavailable
+	}
+	return nil
+}
+
+// unredeemPromotions reverses redeemPromotions for an order that was not
+// created. Failures are only logged since the order has already failed.
+func unredeemPromotions(codes []string) {
+	for _, code := range codes {
+		if err := promotionUsage.Unredeem(code); err != nil {
+			log.Println("unredeemPromotions error: ", code, err)
+		}
+	}
+}
+
+// applyPromotions sets the discount of each priced line from promotions and
+// returns the total discount and whether shipping is free
+func applyPromotions(items OrderItems, categories []string, found []promotions.Promotion) (float64, bool) {
+	lines := make([]promotions.Line, len(items))
+	for i, item := range items {
+		lines[i] = promotions.Line{
+			ProductID: item.ProductID,
+			Category:  categories[i],
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	_, freeShipping := promotions.Apply(lines, found)
+
+	var discount float64
+	for i := range items {
+		items[i].Discount = lines[i].Discount
+		discount += float64(lines[i].Discount)
+	}
+	return discount, freeShipping
+}
+
+// indexPromotions keys promotions by their normalized code
+func indexPromotions(values []promotions.Promotion) map[string]promotions.Promotion {
+	index := make(map[string]promotions.Promotion, len(values))
+	for _, p := range values {
+		p.Code = promotions.NormalizeCode(p.Code)
+		index[p.Code] = p
+	}
+	return index
+}
@@ -0,0 +1,69 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"testing"
+
+	"shared/promotions"
+)
+
+func TestCreateOrderRedeemsPromotions(t *testing.T) {
+	tests := []struct {
+		name       string
+		used       int
+		cartID     string
+		stock      map[string]int
+		wantErr    bool
+		wantFields []string
+		wantUsed   int
+	}{
+		{name: "redeemed", used: 0, wantUsed: 1},
+		{name: "used up", used: 1, wantFields: []string{"promotion_codes[0]"}, wantUsed: 1},
+		{name: "given back when stock can't be taken", used: 0, cartID: "cart-1", stock: map[string]int{"apple": 0}, wantErr: true, wantUsed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog, restore := useFakeCatalog()
+			defer restore()
+			for id, quantity := range tt.stock {
+				catalog.stock[id] = quantity
+			}
+
+			savedPromotions, savedUsage := promotionsByCode, promotionUsage
+			defer func() { promotionsByCode, promotionUsage = savedPromotions, savedUsage }()
+			promotionsByCode = indexPromotions([]promotions.Promotion{{Code: "ONCE", Type: promotions.PercentOff, Value: 10, UsageLimit: 1}})
+			usage := promotions.NewMemoryUsageStore()
+			promotionUsage = usage
+			for i := 0; i < tt.used; i++ {
+				usage.Redeem("ONCE", 0)
+			}
+
+			order := newTestOrder(tt.cartID)
+			order.PromotionCodes = []string{"once"}
+			created, err := RepoCreateOrder(order)
+			switch {
+			case len(tt.wantFields) > 0:
+				verr, ok := err.(*ValidationError)
+				if !ok || len(verr.Errors) != len(tt.wantFields) || verr.Errors[0].Field != tt.wantFields[0] {
+					t.Fatalf("RepoCreateOrder() error = %v, want invalid %v", err, tt.wantFields)
+				}
+			case tt.wantErr:
+				if err == nil {
+					t.Fatalf("RepoCreateOrder() succeeded, want an error")
+				}
+			case err != nil:
+				t.Fatalf("RepoCreateOrder() error = %v", err)
+			case created.Discount == 0:
+				t.Errorf("Discount = 0, want the promotion applied")
+			}
+
+			counts, _ := usage.TimesUsed([]string{"ONCE"})
+			if counts["ONCE"] != tt.wantUsed {
+				t.Errorf("times used = %d, want %d", counts["ONCE"], tt.wantUsed)
+			}
+		})
+	}
+}
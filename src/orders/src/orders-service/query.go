@@ -0,0 +1,267 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes for order listings
+const (
+	defaultOrderPageSize = 100
+	maxOrderPageSize     = 1000
+)
+
+// Sort orders for order listings. A leading "-" sorts descending. Sorting
+// follows the created_at key of the username and status indexes, so it needs
+// one of those filters.
+const (
+	SortCreatedAtDesc = "-created_at"
+	SortCreatedAtAsc  = "created_at"
+)
+
+// OrderQuery Struct - filters, sort order and page of an order listing. Zero
+// values don't filter. Without a sort order, orders are listed in the order
+// the repository stores them.
+type OrderQuery struct {
+	Username      string
+	Status        string
+	DeliveryType  string
+	Channel       string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	MinTotal      *float32
+	Sort          string
+	Limit         int
+	Cursor        string
+}
+
+// OrderPage Struct - a page of orders and the cursor for the next page, which
+// is empty on the last page
+type OrderPage struct {
+	Orders     Orders
+	NextCursor string
+}
+
+// QueryError is returned for an invalid query parameter
+type QueryError struct {
+	Parameter string `json:"parameter"`
+	Message   string `json:"message"`
+}
+
+func (e *QueryError) Error() string {
+	return e.Parameter + ": " + e.Message
+}
+
+// orderCursor is the key of the order after which the next page starts, for
+// the query's sort order and the index it reads. It is encoded so clients
+// treat it as opaque.
+type orderCursor struct {
+	Sort  string            `json:"s"`
+	Index string            `json:"x"`
+	Key   map[string]string `json:"k"`
+}
+
+// ParseOrderQuery reads an OrderQuery from URL query parameters
+func ParseOrderQuery(values url.Values) (OrderQuery, error) {
+	q := OrderQuery{
+		Username:     values.Get("username"),
+		Status:       strings.ToUpper(values.Get("status")),
+		DeliveryType: strings.ToUpper(values.Get("delivery_type")),
+		Channel:      values.Get("channel"),
+		Sort:         values.Get("sort"),
+		Limit:        defaultOrderPageSize,
+		Cursor:       values.Get("cursor"),
+	}
+
+	if len(q.Status) > 0 {
+		if _, ok := transitions[q.Status]; !ok {
+			return q, &QueryError{Parameter: "status", Message: ErrUnknownStatus.Error()}
+		}
+	}
+
+	var err error
+	if q.CreatedAfter, err = parseTimeParam(values, "created_after"); err != nil {
+		return q, err
+	}
+	if q.CreatedBefore, err = parseTimeParam(values, "created_before"); err != nil {
+		return q, err
+	}
+
+	if value := values.Get("min_total"); len(value) > 0 {
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return q, &QueryError{Parameter: "min_total", Message: "must be a number"}
+		}
+		minTotal := float32(f)
+		q.MinTotal = &minTotal
+	}
+
+	switch q.Sort {
+	case "":
+	case SortCreatedAtDesc, SortCreatedAtAsc:
+		if len(q.index()) == 0 {
+			return q, &QueryError{Parameter: "sort", Message: "needs a username or status"}
+		}
+	default:
+		return q, &QueryError{Parameter: "sort", Message: "must be created_at or -created_at"}
+	}
+
+	if value := values.Get("limit"); len(value) > 0 {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxOrderPageSize {
+			return q, &QueryError{Parameter: "limit", Message: "must be between 1 and " + strconv.Itoa(maxOrderPageSize)}
+		}
+		q.Limit = limit
+	}
+
+	return q, nil
+}
+
+// parseTimeParam parses an RFC 3339 timestamp parameter
+func parseTimeParam(values url.Values, name string) (time.Time, error) {
+	value := values.Get(name)
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, &QueryError{Parameter: name, Message: "must be an RFC 3339 timestamp"}
+	}
+	return t, nil
+}
+
+// Matches reports whether the order passes the query's filters
+func (q OrderQuery) Matches(o Order) bool {
+	if len(q.Username) > 0 && o.Username != q.Username {
+		return false
+	}
+	if len(q.Status) > 0 && o.Status != q.Status {
+		return false
+	}
+	if len(q.DeliveryType) > 0 && o.DeliveryType != q.DeliveryType {
+		return false
+	}
+	if len(q.Channel) > 0 && o.Channel != q.Channel {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && o.CreatedAt.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !o.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	if q.MinTotal != nil && o.Total < *q.MinTotal {
+		return false
+	}
+	return true
+}
+
+// index names the repository lookup the query reads: "username", "status",
+// or "" for every order
+func (q OrderQuery) index() string {
+	switch {
+	case len(q.Username) > 0:
+		return "username"
+	case len(q.Status) > 0:
+		return "status"
+	}
+	return ""
+}
+
+// StartKey returns the key of the order the page starts after, or nil for
+// the first page
+func (q OrderQuery) StartKey() (map[string]string, error) {
+	if len(q.Cursor) == 0 {
+		return nil, nil
+	}
+	cursor, err := decodeOrderCursor(q.Cursor)
+	if err != nil || cursor.Sort != q.Sort || cursor.Index != q.index() || len(cursor.Key) == 0 {
+		return nil, &QueryError{Parameter: "cursor", Message: "is not valid for this query"}
+	}
+	return cursor.Key, nil
+}
+
+// NextCursor returns the cursor for the page after the order with key
+func (q OrderQuery) NextCursor(key map[string]string) string {
+	return encodeOrderCursor(orderCursor{Sort: q.Sort, Index: q.index(), Key: key})
+}
+
+// before reports whether a comes before b in the query's sort order. Orders
+// that tie, and unsorted orders, are ordered by ID so every order has a
+// single position.
+func (q OrderQuery) before(a Order, b Order) bool {
+	if len(q.Sort) > 0 && !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt) == (q.Sort == SortCreatedAtAsc)
+	}
+	if a.ID == b.ID {
+		return false
+	}
+	return idLess(a.ID, b.ID) == (q.Sort != SortCreatedAtDesc)
+}
+
+// Page filters and sorts orders in memory and returns the page following the
+// query's cursor. It serves repositories that hold every order in memory.
+func (q OrderQuery) Page(orders Orders) (OrderPage, error) {
+	key, err := q.StartKey()
+	if err != nil {
+		return OrderPage{}, err
+	}
+	var after *Order
+	if key != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, key["created_at"])
+		if err != nil {
+			return OrderPage{}, &QueryError{Parameter: "cursor", Message: "is not valid for this query"}
+		}
+		after = &Order{ID: key["id"], CreatedAt: createdAt}
+	}
+
+	matched := Orders{}
+	for _, o := range orders {
+		if q.Matches(o) && (after == nil || q.before(*after, o)) {
+			matched = append(matched, o)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return q.before(matched[i], matched[j]) })
+
+	page := OrderPage{Orders: matched}
+	if len(matched) > q.Limit {
+		page.Orders = matched[:q.Limit]
+		last := page.Orders[q.Limit-1]
+		page.NextCursor = q.NextCursor(map[string]string{"id": last.ID, "created_at": last.CreatedAt.Format(time.RFC3339Nano)})
+	}
+	return page, nil
+}
+
+func encodeOrderCursor(c orderCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOrderCursor(value string) (orderCursor, error) {
+	var c orderCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// idLess compares order IDs, as numbers when both are numeric so "10" sorts
+// after "9"
+func idLess(a string, b string) bool {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
@@ -0,0 +1,188 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseOrderQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		values        url.Values
+		wantParameter string
+	}{
+		{name: "no parameters", values: url.Values{}},
+		{name: "filters", values: url.Values{"username": {"ada"}, "status": {"shipped"}, "delivery_type": {"delivery"}, "min_total": {"10.5"}}},
+		{name: "created range", values: url.Values{"created_after": {"2024-01-01T00:00:00Z"}, "created_before": {"2024-02-01T00:00:00Z"}}},
+		{name: "unknown status", values: url.Values{"status": {"LOST"}}, wantParameter: "status"},
+		{name: "bad timestamp", values: url.Values{"created_after": {"yesterday"}}, wantParameter: "created_after"},
+		{name: "bad total", values: url.Values{"min_total": {"lots"}}, wantParameter: "min_total"},
+		{name: "sort by username", values: url.Values{"username": {"ada"}, "sort": {"-created_at"}}},
+		{name: "sort by status", values: url.Values{"status": {"PENDING"}, "sort": {"created_at"}}},
+		{name: "sort without an index", values: url.Values{"sort": {"-created_at"}}, wantParameter: "sort"},
+		{name: "unknown sort", values: url.Values{"username": {"ada"}, "sort": {"total"}}, wantParameter: "sort"},
+		{name: "limit", values: url.Values{"limit": {"1000"}}},
+		{name: "limit too small", values: url.Values{"limit": {"0"}}, wantParameter: "limit"},
+		{name: "limit too large", values: url.Values{"limit": {"1001"}}, wantParameter: "limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOrderQuery(tt.values)
+			if len(tt.wantParameter) == 0 {
+				if err != nil {
+					t.Fatalf("ParseOrderQuery() error = %v", err)
+				}
+				return
+			}
+			qerr, ok := err.(*QueryError)
+			if !ok || qerr.Parameter != tt.wantParameter {
+				t.Fatalf("ParseOrderQuery() error = %v, want error for %s", err, tt.wantParameter)
+			}
+		})
+	}
+}
+
+func TestOrderQueryPage(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	orders := Orders{
+		{ID: "1", Username: "ada", Status: StatusPending, Total: 10, CreatedAt: start},
+		{ID: "2", Username: "bob", Status: StatusPending, Total: 20, CreatedAt: start.Add(time.Hour)},
+		{ID: "3", Username: "ada", Status: StatusShipped, Total: 30, CreatedAt: start.Add(2 * time.Hour)},
+		{ID: "10", Username: "ada", Status: StatusPending, Total: 40, CreatedAt: start.Add(2 * time.Hour)},
+		{ID: "4", Username: "ada", Status: StatusPending, Total: 50, CreatedAt: start.Add(3 * time.Hour)},
+	}
+	minTotal := float32(25)
+
+	tests := []struct {
+		name  string
+		query OrderQuery
+		want  [][]string
+	}{
+		{
+			name:  "unsorted by ID",
+			query: OrderQuery{Limit: 2},
+			want:  [][]string{{"1", "2"}, {"3", "4"}, {"10"}},
+		},
+		{
+			name:  "newest first, ties by ID",
+			query: OrderQuery{Username: "ada", Sort: SortCreatedAtDesc, Limit: 2},
+			want:  [][]string{{"4", "10"}, {"3", "1"}},
+		},
+		{
+			name:  "oldest first",
+			query: OrderQuery{Username: "ada", Sort: SortCreatedAtAsc, Limit: 3},
+			want:  [][]string{{"1", "3", "10"}, {"4"}},
+		},
+		{
+			name:  "filters",
+			query: OrderQuery{Status: StatusPending, MinTotal: &minTotal, Limit: 10},
+			want:  [][]string{{"4", "10"}},
+		},
+		{
+			name:  "created range",
+			query: OrderQuery{CreatedAfter: start.Add(time.Hour), CreatedBefore: start.Add(3 * time.Hour), Limit: 10},
+			want:  [][]string{{"2", "3", "10"}},
+		},
+		{
+			name:  "nothing matches",
+			query: OrderQuery{Username: "eve", Limit: 10},
+			want:  [][]string{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.query
+			got := [][]string{}
+			for {
+				page, err := q.Page(orders)
+				if err != nil {
+					t.Fatalf("Page() error = %v", err)
+				}
+				ids := []string{}
+				for _, o := range page.Orders {
+					ids = append(ids, o.ID)
+				}
+				got = append(got, ids)
+				if len(page.NextCursor) == 0 || len(got) > len(orders) {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderQueryCursorMismatch(t *testing.T) {
+	first := OrderQuery{Username: "ada", Sort: SortCreatedAtDesc}
+	cursor := first.NextCursor(map[string]string{"id": "1", "created_at": "2024-06-01T12:00:00Z"})
+
+	tests := []struct {
+		name  string
+		query OrderQuery
+		valid bool
+	}{
+		{name: "same query", query: OrderQuery{Username: "ada", Sort: SortCreatedAtDesc, Cursor: cursor}, valid: true},
+		{name: "other username", query: OrderQuery{Username: "bob", Sort: SortCreatedAtDesc, Cursor: cursor}, valid: true},
+		{name: "other sort", query: OrderQuery{Username: "ada", Sort: SortCreatedAtAsc, Cursor: cursor}},
+		{name: "other index", query: OrderQuery{Status: StatusPending, Sort: SortCreatedAtDesc, Cursor: cursor}},
+		{name: "garbage", query: OrderQuery{Username: "ada", Sort: SortCreatedAtDesc, Cursor: "not a cursor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.query.StartKey()
+			if tt.valid != (err == nil) {
+				t.Errorf("StartKey() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestMemoryOrderRepositoryQuery(t *testing.T) {
+	repository := NewMemoryOrderRepository()
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, username := range []string{"ada", "bob", "ada"} {
+		order := Order{ID: strconv.Itoa(i + 1), Username: username, Status: StatusPending, CreatedAt: start.Add(time.Duration(i) * time.Hour)}
+		if _, err := repository.Create(order); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query OrderQuery
+		want  []string
+	}{
+		{name: "every order", query: OrderQuery{Limit: 10}, want: []string{"1", "2", "3"}},
+		{name: "by username", query: OrderQuery{Username: "ada", Sort: SortCreatedAtDesc, Limit: 10}, want: []string{"3", "1"}},
+		{name: "by status", query: OrderQuery{Status: StatusPending, Username: "bob", Limit: 10}, want: []string{"2"}},
+		{name: "by other status", query: OrderQuery{Status: StatusShipped, Limit: 10}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repository.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			ids := []string{}
+			for _, o := range page.Orders {
+				ids = append(ids, o.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Query() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -4,60 +4,245 @@
 package main
 
 import (
-	"strconv"
+	"log"
+	"strings"
+	"time"
+
+	guuuid "github.com/google/uuid"
+
+	"shared/address"
 )
 
-var currentID int
+var orderRepository OrderRepository
 
-var orders Orders = Orders{}
+// maxUpdateAttempts bounds retries of updates that lose a race with another
+// writer
+const maxUpdateAttempts = 5
 
 // Init
 func init() {
+	orderRepository = NewOrderRepository()
+}
+
+// RepoFindAllOrders Function
+func RepoFindAllOrders() Orders {
+	values, err := orderRepository.FindAll()
+	if err != nil {
+		log.Println("RepoFindAllOrders error: ", err)
+		return Orders{}
+	}
+	return values
 }
 
 // RepoFindOrderByID Function
 func RepoFindOrderByID(id string) Order {
-	for _, t := range orders {
-		if t.ID == id {
-			return t
+	order, err := orderRepository.FindByID(id)
+	if err != nil {
+		if err != ErrOrderNotFound {
+			log.Println("RepoFindOrderByID error: ", err)
 		}
+		// return empty Order if not found
+		return Order{}
 	}
-	// return empty Order if not found
-	return Order{}
+	return order
 }
 
 // RepoFindOrdersByUsername Function
 func RepoFindOrdersByUsername(username string) Orders {
+	values, err := orderRepository.FindByUsername(username)
+	if err != nil {
+		log.Println("RepoFindOrdersByUsername error: ", err)
+		return Orders{}
+	}
+	return values
+}
 
-	var o Orders = Orders{}
+// RepoQueryOrders Function
+func RepoQueryOrders(q OrderQuery) (OrderPage, error) {
+	page, err := orderRepository.Query(q)
+	if err != nil {
+		if _, ok := err.(*QueryError); !ok {
+			log.Println("RepoQueryOrders error: ", err)
+		}
+		return OrderPage{}, err
+	}
+	return page, nil
+}
 
-	for _, t := range orders {
-		if t.Username == username {
-			o = append(o, t)
+// RepoUpdateOrder Function
+// The status, its history and the delivery fields derived from it are kept
+// from the stored order; they only change through RepoTransitionOrder. The
+// items, promotions and amounts priced when the order was created are kept,
+// since refunds and restocking are worked out from them. The creation time,
+// cancellation, returns, refunds, shipments, shipping option, pickup booking
+// and unpublished events are kept as well, along with the cart the order
+// was checked out from and whether it took stock. The order was taxed and
+// its shipping priced for its owner, delivery type and addresses, so a
+// request that changes them fails validation.
+func RepoUpdateOrder(t Order) (Order, error) {
+	return RepoMutateOrder(t.ID, func(o *Order) error {
+		if err := checkUnchanged(t, *o); err != nil {
+			return err
 		}
+
+		t.Items = o.Items
+		t.Subtotal = o.Subtotal
+		t.Discount = o.Discount
+		t.Tax = o.Tax
+		t.TaxLines = o.TaxLines
+		t.Total = o.Total
+		t.PromotionCodes = o.PromotionCodes
+		t.FreeShipping = o.FreeShipping
+		t.Status = o.Status
+		t.History = o.History
+		t.DeliveryStatus = o.DeliveryStatus
+		t.DeliveryComplete = o.DeliveryComplete
+		t.CreatedAt = o.CreatedAt
+		t.Returns = o.Returns
+		t.Refunds = o.Refunds
+		t.RefundTotal = o.RefundTotal
+		t.Shipments = o.Shipments
+		t.FulfillmentStatus = o.FulfillmentStatus
+		t.Cancellation = o.Cancellation
+		t.CartID = o.CartID
+		t.StockTaken = o.StockTaken
+		t.Shipping = o.Shipping
+		t.Pickup = o.Pickup
+		t.Outbox = o.Outbox
+		t.Version = o.Version
+		*o = t
+		return nil
+	})
+}
+
+// checkUnchanged returns a ValidationError for each field of update that the
+// order was priced for and that differs from the stored order. Delivery types
+// and addresses are compared after normalizing, as they were stored.
+func checkUnchanged(update Order, stored Order) error {
+	verr := &ValidationError{}
+
+	if update.Username != stored.Username {
+		verr.Add("username", "can't be changed")
+	}
+	deliveryType := strings.ToUpper(strings.TrimSpace(update.DeliveryType))
+	if len(deliveryType) == 0 {
+		deliveryType = DeliveryTypeDelivery
+	}
+	if deliveryType != stored.DeliveryType {
+		verr.Add("delivery_type", "can't be changed")
+	}
+	if !sameAddress(update.BillingAddress, stored.BillingAddress) {
+		verr.Add("billing_address", "can't be changed")
+	}
+	if !sameAddress(update.ShippingAddress, stored.ShippingAddress) {
+		verr.Add("shipping_address", "can't be changed")
+	}
+
+	return verr.OrNil()
+}
+
+// sameAddress reports whether a is the stored address. Addresses an order
+// doesn't use, such as the shipping address of a collection order, are
+// stored as given rather than normalized.
+func sameAddress(a Address, stored Address) bool {
+	return a == stored || address.Normalize(a) == stored
+}
+
+// RepoTransitionOrder Function
+// Cancelling an order goes through RepoCancelOrder so its stock is restored.
+func RepoTransitionOrder(id string, request TransitionRequest) (Order, error) {
+	if normalizeStatus(request.Status) == StatusCancelled {
+		return RepoCancelOrder(id, CancelRequest{Reason: request.Reason, Actor: request.Actor})
 	}
 
-	return o
+	return RepoMutateOrder(id, func(o *Order) error {
+		return o.Transition(request.Status, request.Actor, request.Reason, time.Now())
+	})
 }
 
-func RepoUpdateOrder(t Order) Order {
+// RepoMutateOrder Function
+// Loads the order, applies mutate to it and stores the result. A write that
+// loses a race with another writer is retried against the newer order. An
+// error returned by mutate aborts the update and is passed through. Events
+// raised by the change are handed to the outbox relay once it is stored.
+func RepoMutateOrder(id string, mutate func(*Order) error) (Order, error) {
+	for attempt := 0; ; attempt++ {
+		order, err := orderRepository.FindByID(id)
+		if err != nil {
+			if err != ErrOrderNotFound {
+				log.Println("RepoMutateOrder error: ", err)
+			}
+			return Order{}, err
+		}
 
-	for i := 0; i < len(orders); i++ {
-		o := &orders[i]
-		if o.ID == t.ID {
-			orders[i] = t
-			return RepoFindOrderByID(t.ID)
+		if err := mutate(&order); err != nil {
+			return Order{}, err
 		}
-	}
+		order.ID = id
 
-	// return empty Order if not found
-	return Order{}
+		updated, err := orderRepository.Update(order)
+		if err != ErrOrderVersionConflict || attempt+1 >= maxUpdateAttempts {
+			if err != nil && err != ErrOrderNotFound && err != ErrOrderVersionConflict {
+				log.Println("RepoMutateOrder error: ", err)
+			}
+			if err == nil && len(updated.Outbox) > 0 {
+				outboxRelay.Notify()
+			}
+			return updated, err
+		}
+	}
 }
 
 // RepoCreateOrder Function
-func RepoCreateOrder(t Order) Order {
-	currentID++
-	t.ID = strconv.Itoa(currentID)
-	orders = append(orders, t)
-	return t
+// Validates the order and prices it from the products service before storing it.
+// Each promotion code is redeemed, counting towards its usage limit, and the
+// order's stock is taken before it is stored: through the cart's reservations
+// for an order checked out from a cart, otherwise through a reservation made
+// for the order. The codes and stock are given back if it can't be stored.
+func RepoCreateOrder(t Order) (Order, error) {
+	if err := t.Validate(); err != nil {
+		return Order{}, err
+	}
+	if err := PriceOrder(&t, productCatalog, taxCalculator, shippingRates); err != nil {
+		return Order{}, err
+	}
+
+	now := time.Now().UTC()
+	// Whole seconds, so created_at sorts as text in the DynamoDB indexes
+	t.CreatedAt = now.Truncate(time.Second)
+	t.Status = ""
+	t.History = nil
+	t.Returns = nil
+	t.Refunds = nil
+	t.RefundTotal = 0
+	t.Shipments = nil
+	t.FulfillmentStatus = FulfillmentUnfulfilled
+	t.Cancellation = nil
+	t.Pickup = nil
+	t.Outbox = nil
+	t.setStatus(StatusPending, t.Username, "Order created", now)
+
+	if err := redeemPromotions(t.PromotionCodes); err != nil {
+		return Order{}, err
+	}
+
+	reservationID := t.CartID
+	if len(reservationID) == 0 {
+		reservationID = "order-" + strings.ToLower(guuuid.New().String())
+	}
+	if err := takeStock(reservationID, t.Items); err != nil {
+		unredeemPromotions(t.PromotionCodes)
+		return Order{}, err
+	}
+	t.StockTaken = true
+
+	created, err := orderRepository.Create(t)
+	if err != nil {
+		log.Println("RepoCreateOrder error: ", err)
+		unredeemPromotions(t.PromotionCodes)
+		restockItems(stockItems(t.Items))
+		return Order{}, err
+	}
+	outboxRelay.Notify()
+	return created, nil
 }
@@ -0,0 +1,128 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ReturnIndex Handler
+func ReturnIndex(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	returns, err := RepoFindReturns(vars["orderID"])
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(returns); err != nil {
+		panic(err)
+	}
+}
+
+// ReturnShow Handler
+func ReturnShow(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	ret, err := RepoFindReturn(vars["orderID"], vars["returnID"])
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(ret); err != nil {
+		panic(err)
+	}
+}
+
+// ReturnCreate Handler
+func ReturnCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var request ReturnRequest
+	if !readJSONBody(w, r, &request) {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	ret, err := RepoRequestReturn(vars["orderID"], request)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(ret); err != nil {
+		panic(err)
+	}
+}
+
+// ReturnTransitionCreate Handler
+func ReturnTransitionCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var request TransitionRequest
+	if !readJSONBody(w, r, &request) {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	ret, err := RepoTransitionReturn(vars["orderID"], vars["returnID"], request)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(ret); err != nil {
+		panic(err)
+	}
+}
+
+// readJSONBody decodes the request body into v, writing a 422 response and
+// returning false if it isn't valid JSON. An empty body leaves v unchanged.
+func readJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		panic(err)
+	}
+	if err := r.Body.Close(); err != nil {
+		panic(err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeOrderError(w, &ValidationError{Errors: []FieldError{{Field: "body", Message: err.Error()}}})
+		return false
+	}
+	return true
+}
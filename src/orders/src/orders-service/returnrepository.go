@@ -0,0 +1,128 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrInventoryUnavailable is returned when returned stock could not be added
+// back to inventory
+var ErrInventoryUnavailable = errors.New("Unable to update product inventory")
+
+// RepoFindReturns Function
+func RepoFindReturns(orderID string) ([]Return, error) {
+	order, err := orderRepository.FindByID(orderID)
+	if err != nil {
+		if err != ErrOrderNotFound {
+			log.Println("RepoFindReturns error: ", err)
+		}
+		return nil, err
+	}
+
+	if order.Returns == nil {
+		return []Return{}, nil
+	}
+	return order.Returns, nil
+}
+
+// RepoFindReturn Function
+func RepoFindReturn(orderID string, returnID string) (Return, error) {
+	returns, err := RepoFindReturns(orderID)
+	if err != nil {
+		return Return{}, err
+	}
+
+	for _, r := range returns {
+		if r.ID == returnID {
+			return r, nil
+		}
+	}
+	return Return{}, ErrReturnNotFound
+}
+
+// RepoRequestReturn Function
+func RepoRequestReturn(orderID string, request ReturnRequest) (Return, error) {
+	var created Return
+	_, err := RepoMutateOrder(orderID, func(o *Order) error {
+		r, err := o.RequestReturn(request, time.Now())
+		created = r
+		return err
+	})
+	if err != nil {
+		return Return{}, err
+	}
+	return created, nil
+}
+
+// RepoTransitionReturn Function
+// Receiving a return adds its items back to inventory in the products service
+// before the return is marked received. If the inventory can't be updated or
+// the order can't be saved, stock already added is taken out again.
+func RepoTransitionReturn(orderID string, returnID string, request TransitionRequest) (Return, error) {
+	var restocked ReturnItems
+	if normalizeStatus(request.Status) == ReturnStatusReceived {
+		order, err := orderRepository.FindByID(orderID)
+		if err != nil {
+			return Return{}, err
+		}
+
+		// Check the transition is allowed before touching inventory
+		r, err := order.TransitionReturn(returnID, request.Status, request.Actor, request.Reason, time.Now())
+		if err != nil {
+			return Return{}, err
+		}
+
+		restocked, err = restockItems(r.Items)
+		if err != nil {
+			unrestockItems(restocked)
+			return Return{}, err
+		}
+	}
+
+	var updated Return
+	_, err := RepoMutateOrder(orderID, func(o *Order) error {
+		r, err := o.TransitionReturn(returnID, request.Status, request.Actor, request.Reason, time.Now())
+		if err != nil {
+			return err
+		}
+		if len(restocked) > 0 {
+			stored, _ := o.FindReturn(returnID)
+			stored.Restocked = true
+			r = *stored
+		}
+		updated = r
+		return nil
+	})
+	if err != nil {
+		unrestockItems(restocked)
+		return Return{}, err
+	}
+	return updated, nil
+}
+
+// restockItems adds returned items back to inventory and returns the items
+// that were successfully restocked
+func restockItems(items ReturnItems) (ReturnItems, error) {
+	restocked := ReturnItems{}
+	for _, item := range items {
+		if err := productCatalog.UpdateInventory(item.ProductID, item.Quantity); err != nil {
+			log.Println("restockItems error: ", item.ProductID, err)
+			return restocked, ErrInventoryUnavailable
+		}
+		restocked = append(restocked, item)
+	}
+	return restocked, nil
+}
+
+// unrestockItems reverses restockItems
+func unrestockItems(items ReturnItems) {
+	for _, item := range items {
+		if err := productCatalog.UpdateInventory(item.ProductID, -item.Quantity); err != nil {
+			log.Println("unrestockItems unable to reverse restock: ", item.ProductID, item.Quantity, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/money"
+)
+
+// Return statuses
+const (
+	ReturnStatusRequested = "REQUESTED"
+	ReturnStatusApproved  = "APPROVED"
+	ReturnStatusRejected  = "REJECTED"
+	ReturnStatusReceived  = "RECEIVED"
+	ReturnStatusRefunded  = "REFUNDED"
+	ReturnStatusCancelled = "CANCELLED"
+)
+
+// returnTransitions lists the statuses a return can move to from each status.
+// REJECTED, REFUNDED and CANCELLED are final.
+var returnTransitions = map[string][]string{
+	ReturnStatusRequested: {ReturnStatusApproved, ReturnStatusRejected, ReturnStatusCancelled},
+	ReturnStatusApproved:  {ReturnStatusReceived, ReturnStatusCancelled},
+	ReturnStatusReceived:  {ReturnStatusRefunded},
+	ReturnStatusRejected:  {},
+	ReturnStatusRefunded:  {},
+	ReturnStatusCancelled: {},
+}
+
+// Reasons a customer can give for returning items
+var returnReasons = map[string]bool{
+	"DAMAGED":          true,
+	"DEFECTIVE":        true,
+	"WRONG_ITEM":       true,
+	"NOT_AS_DESCRIBED": true,
+	"NO_LONGER_NEEDED": true,
+	"OTHER":            true,
+}
+
+// Errors returned by return operations
+var (
+	ErrReturnNotFound     = errors.New("Return not found")
+	ErrOrderNotReturnable = errors.New("Only delivered orders can be returned")
+)
+
+// Return Struct - a request to send back some of an order's items
+type Return struct {
+	ID           string         `json:"id" yaml:"id"`
+	Items        ReturnItems    `json:"items" yaml:"items"`
+	Reason       string         `json:"reason" yaml:"reason"`
+	Comment      string         `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Status       string         `json:"status" yaml:"status"`
+	RefundAmount float32        `json:"refund_amount" yaml:"refund_amount"`
+	Restocked    bool           `json:"restocked" yaml:"restocked"`
+	History      []StatusChange `json:"history" yaml:"history"`
+	CreatedAt    time.Time      `json:"created_at" yaml:"created_at"`
+}
+
+// ReturnItem Struct - a quantity of one product being returned and the
+// amount refunded for it
+type ReturnItem struct {
+	ProductID string  `json:"product_id" yaml:"product_id"`
+	Quantity  int     `json:"quantity" yaml:"quantity"`
+	Refund    float32 `json:"refund" yaml:"refund"`
+}
+
+// ReturnItems Array
+type ReturnItems []ReturnItem
+
+// Refund Struct - money paid back to the customer for a return
+type Refund struct {
+	ReturnID  string    `json:"return_id" yaml:"return_id"`
+	Amount    float32   `json:"amount" yaml:"amount"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// ReturnRequest Struct - request body for returning items
+type ReturnRequest struct {
+	Items   ReturnItems `json:"items" yaml:"items"`
+	Reason  string      `json:"reason" yaml:"reason"`
+	Comment string      `json:"comment" yaml:"comment"`
+	Actor   string      `json:"actor" yaml:"actor"`
+}
+
+// FindReturn returns a pointer to the order's return with id
+func (o *Order) FindReturn(id string) (*Return, error) {
+	for i := range o.Returns {
+		if o.Returns[i].ID == id {
+			return &o.Returns[i], nil
+		}
+	}
+	return nil, ErrReturnNotFound
+}
+
+// returnableQuantities returns how many of each product can still be
+// returned: the quantity ordered less what open or completed returns cover
+func (o *Order) returnableQuantities() map[string]int {
+	quantities := map[string]int{}
+	for _, item := range o.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	for _, r := range o.Returns {
+		if r.Status == ReturnStatusRejected || r.Status == ReturnStatusCancelled {
+			continue
+		}
+		for _, item := range r.Items {
+			quantities[item.ProductID] -= item.Quantity
+		}
+	}
+	return quantities
+}
+
+// unitRefund returns what was paid for one unit of a product: the original
+// line price less its share of the line discount plus its share of the line
+// tax. Products ordered on several lines are refunded at their average price.
+func (o *Order) unitRefund(productID string) float64 {
+	var paid float64
+	var quantity int
+	for _, item := range o.Items {
+		if item.ProductID == productID {
+			paid += float64(item.Price)*float64(item.Quantity) - float64(item.Discount) + float64(item.Tax)
+			quantity += item.Quantity
+		}
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return paid / float64(quantity)
+}
+
+// RequestReturn adds a return for the requested items with refunds computed
+// from the order's line prices. Only delivered orders can be returned, and
+// each product only up to the quantity not already being returned.
+func (o *Order) RequestReturn(request ReturnRequest, now time.Time) (Return, error) {
+	if o.Status != StatusDelivered {
+		return Return{}, ErrOrderNotReturnable
+	}
+
+	verr := &ValidationError{}
+
+	reason := strings.ToUpper(strings.TrimSpace(request.Reason))
+	if !returnReasons[reason] {
+		verr.Add("reason", "must be one of DAMAGED, DEFECTIVE, WRONG_ITEM, NOT_AS_DESCRIBED, NO_LONGER_NEEDED or OTHER")
+	}
+	if len(request.Items) == 0 {
+		verr.Add("items", "must contain at least one item")
+	}
+
+	returnable := o.returnableQuantities()
+	items := ReturnItems{}
+	var total float64
+	for i, item := range request.Items {
+		field := "items[" + strconv.Itoa(i) + "]"
+		available, ordered := returnable[item.ProductID]
+		switch {
+		case !ordered:
+			verr.Add(field+".product_id", "is not part of the order")
+		case item.Quantity <= 0:
+			verr.Add(field+".quantity", "must be greater than zero")
+		case item.Quantity > available:
+			verr.Add(field+".quantity", "must not exceed the "+strconv.Itoa(available)+" that can be returned")
+		default:
+			returnable[item.ProductID] -= item.Quantity
+			refund := o.unitRefund(item.ProductID) * float64(item.Quantity)
+			total += refund
+			items = append(items, ReturnItem{ProductID: item.ProductID, Quantity: item.Quantity, Refund: money.Round(refund)})
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		return Return{}, err
+	}
+
+	r := Return{
+		ID:           strconv.Itoa(len(o.Returns) + 1),
+		Items:        items,
+		Reason:       reason,
+		Comment:      request.Comment,
+		RefundAmount: money.Round(total),
+		CreatedAt:    now.UTC(),
+	}
+	r.setStatus(ReturnStatusRequested, request.Actor, "", now)
+	o.Returns = append(o.Returns, r)
+	return r, nil
+}
+
+// TransitionReturn moves the return to status. Refunding a return records
+// the refund on the order.
+func (o *Order) TransitionReturn(id string, status string, actor string, reason string, now time.Time) (Return, error) {
+	r, err := o.FindReturn(id)
+	if err != nil {
+		return Return{}, err
+	}
+
+	status = normalizeStatus(status)
+	if _, ok := returnTransitions[status]; !ok {
+		return Return{}, ErrUnknownStatus
+	}
+
+	allowed := returnTransitions[r.Status]
+	legal := false
+	for _, s := range allowed {
+		if s == status {
+			legal = true
+			break
+		}
+	}
+	if !legal {
+		return Return{}, &TransitionError{
+			From:    r.Status,
+			To:      status,
+			Allowed: allowed,
+			Message: "Return cannot move from " + r.Status + " to " + status,
+		}
+	}
+
+	r.setStatus(status, actor, reason, now)
+	if status == ReturnStatusRefunded {
+		o.Refunds = append(o.Refunds, Refund{ReturnID: r.ID, Amount: r.RefundAmount, Timestamp: now.UTC()})
+		o.RefundTotal = money.Round(float64(o.RefundTotal) + float64(r.RefundAmount))
+	}
+	return *r, nil
+}
+
+// setStatus records the change to status in the return's history
+func (r *Return) setStatus(status string, actor string, reason string, now time.Time) {
+	if len(actor) == 0 {
+		actor = "system"
+	}
+
+	r.History = append(r.History, StatusChange{
+		From:      r.Status,
+		To:        status,
+		Timestamp: now.UTC(),
+		Actor:     actor,
+		Reason:    reason,
+	})
+	r.Status = status
+}
@@ -65,4 +65,154 @@ var routes = Routes{
         "/orders/id/{orderID}",
         OrderUpdate,
     },
+    Route{
+        "OrderTransitionIndex",
+        "GET",
+        "/orders/id/{orderID}/transitions",
+        OrderTransitionIndex,
+    },
+    Route{
+        "OrderTransitionCreate",
+        "POST",
+        "/orders/id/{orderID}/transitions",
+        OrderTransitionCreate,
+    },
+    Route{
+        "OrderTransitionCreate",
+        "OPTIONS",
+        "/orders/id/{orderID}/transitions",
+        OrderTransitionCreate,
+    },
+    Route{
+        "ReturnIndex",
+        "GET",
+        "/orders/id/{orderID}/returns",
+        ReturnIndex,
+    },
+    Route{
+        "ReturnCreate",
+        "POST",
+        "/orders/id/{orderID}/returns",
+        ReturnCreate,
+    },
+    Route{
+        "ReturnCreate",
+        "OPTIONS",
+        "/orders/id/{orderID}/returns",
+        ReturnCreate,
+    },
+    Route{
+        "ReturnShow",
+        "GET",
+        "/orders/id/{orderID}/returns/{returnID}",
+        ReturnShow,
+    },
+    Route{
+        "ReturnTransitionCreate",
+        "POST",
+        "/orders/id/{orderID}/returns/{returnID}/transitions",
+        ReturnTransitionCreate,
+    },
+    Route{
+        "ReturnTransitionCreate",
+        "OPTIONS",
+        "/orders/id/{orderID}/returns/{returnID}/transitions",
+        ReturnTransitionCreate,
+    },
+    Route{
+        "ShipmentIndex",
+        "GET",
+        "/orders/id/{orderID}/shipments",
+        ShipmentIndex,
+    },
+    Route{
+        "ShipmentCreate",
+        "POST",
+        "/orders/id/{orderID}/shipments",
+        ShipmentCreate,
+    },
+    Route{
+        "ShipmentCreate",
+        "OPTIONS",
+        "/orders/id/{orderID}/shipments",
+        ShipmentCreate,
+    },
+    Route{
+        "ShipmentShow",
+        "GET",
+        "/orders/id/{orderID}/shipments/{shipmentID}",
+        ShipmentShow,
+    },
+    Route{
+        "ShipmentEventCreate",
+        "POST",
+        "/orders/id/{orderID}/shipments/{shipmentID}/events",
+        ShipmentEventCreate,
+    },
+    Route{
+        "ShipmentEventCreate",
+        "OPTIONS",
+        "/orders/id/{orderID}/shipments/{shipmentID}/events",
+        ShipmentEventCreate,
+    },
+    Route{
+        "PickupCreate",
+        "POST",
+        "/orders/id/{orderID}/pickup",
+        PickupCreate,
+    },
+    Route{
+        "PickupCreate",
+        "OPTIONS",
+        "/orders/id/{orderID}/pickup",
+        PickupCreate,
+    },
+    Route{
+        "ArrivalCreate",
+        "POST",
+        "/orders/id/{orderID}/arrived",
+        ArrivalCreate,
+    },
+    Route{
+        "ArrivalCreate",
+        "OPTIONS",
+        "/orders/id/{orderID}/arrived",
+        ArrivalCreate,
+    },
+    Route{
+        "PickupSlotIndex",
+        "GET",
+        "/orders/stores/{storeID}/pickup-slots",
+        PickupSlotIndex,
+    },
+    Route{
+        "PickupQueueIndex",
+        "GET",
+        "/orders/stores/{storeID}/pickup-queue",
+        PickupQueueIndex,
+    },
+    Route{
+        "OrderCancel",
+        "POST",
+        "/orders/id/{orderID}/cancel",
+        OrderCancel,
+    },
+    Route{
+        "OrderCancel",
+        "OPTIONS",
+        "/orders/id/{orderID}/cancel",
+        OrderCancel,
+    },
+    Route{
+        "ShippingQuoteCreate",
+        "POST",
+        "/shipping/quotes",
+        ShippingQuoteCreate,
+    },
+    Route{
+        "ShippingQuoteCreate",
+        "OPTIONS",
+        "/shipping/quotes",
+        ShippingQuoteCreate,
+    },
 }
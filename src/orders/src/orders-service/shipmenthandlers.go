@@ -0,0 +1,105 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ShipmentIndex Handler
+func ShipmentIndex(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	shipments, err := RepoFindShipments(vars["orderID"])
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(shipments); err != nil {
+		panic(err)
+	}
+}
+
+// ShipmentShow Handler
+func ShipmentShow(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+
+	vars := mux.Vars(r)
+
+	shipment, err := RepoFindShipment(vars["orderID"], vars["shipmentID"])
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(shipment); err != nil {
+		panic(err)
+	}
+}
+
+// ShipmentCreate Handler
+func ShipmentCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var request ShipmentRequest
+	if !readJSONBody(w, r, &request) {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	shipment, err := RepoCreateShipment(vars["orderID"], request)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(shipment); err != nil {
+		panic(err)
+	}
+}
+
+// ShipmentEventCreate Handler
+func ShipmentEventCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var event TrackingEvent
+	if !readJSONBody(w, r, &event) {
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	shipment, err := RepoAddTrackingEvent(vars["orderID"], vars["shipmentID"], event)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(shipment); err != nil {
+		panic(err)
+	}
+}
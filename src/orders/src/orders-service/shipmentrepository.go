@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// RepoFindShipments Function
+func RepoFindShipments(orderID string) ([]Shipment, error) {
+	order, err := orderRepository.FindByID(orderID)
+	if err != nil {
+		if err != ErrOrderNotFound {
+			log.Println("RepoFindShipments error: ", err)
+		}
+		return nil, err
+	}
+
+	if order.Shipments == nil {
+		return []Shipment{}, nil
+	}
+	return order.Shipments, nil
+}
+
+// RepoFindShipment Function
+func RepoFindShipment(orderID string, shipmentID string) (Shipment, error) {
+	shipments, err := RepoFindShipments(orderID)
+	if err != nil {
+		return Shipment{}, err
+	}
+
+	for _, s := range shipments {
+		if s.ID == shipmentID {
+			return s, nil
+		}
+	}
+	return Shipment{}, ErrShipmentNotFound
+}
+
+// RepoCreateShipment Function
+func RepoCreateShipment(orderID string, request ShipmentRequest) (Shipment, error) {
+	var created Shipment
+	_, err := RepoMutateOrder(orderID, func(o *Order) error {
+		s, err := o.CreateShipment(request, time.Now())
+		created = s
+		return err
+	})
+	if err != nil {
+		return Shipment{}, err
+	}
+	return created, nil
+}
+
+// RepoAddTrackingEvent Function
+func RepoAddTrackingEvent(orderID string, shipmentID string, event TrackingEvent) (Shipment, error) {
+	var updated Shipment
+	_, err := RepoMutateOrder(orderID, func(o *Order) error {
+		s, err := o.AddTrackingEvent(shipmentID, event, time.Now())
+		updated = s
+		return err
+	})
+	if err != nil {
+		return Shipment{}, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,231 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shipment statuses. A shipment starts out CREATED when its label is made and
+// then follows the tracking events reported by the carrier.
+const (
+	ShipmentStatusCreated        = "CREATED"
+	ShipmentStatusInTransit      = "IN_TRANSIT"
+	ShipmentStatusOutForDelivery = "OUT_FOR_DELIVERY"
+	ShipmentStatusDelivered      = "DELIVERED"
+	ShipmentStatusException      = "EXCEPTION"
+)
+
+// Statuses a tracking event can report
+var trackingStatuses = map[string]bool{
+	ShipmentStatusInTransit:      true,
+	ShipmentStatusOutForDelivery: true,
+	ShipmentStatusDelivered:      true,
+	ShipmentStatusException:      true,
+}
+
+// Fulfillment statuses, derived from an order's shipments
+const (
+	FulfillmentUnfulfilled      = "UNFULFILLED"
+	FulfillmentPartiallyShipped = "PARTIALLY_SHIPPED"
+	FulfillmentShipped          = "SHIPPED"
+	FulfillmentDelivered        = "DELIVERED"
+)
+
+// Errors returned by shipment operations
+var (
+	ErrShipmentNotFound  = errors.New("Shipment not found")
+	ErrOrderNotShippable = errors.New("Only delivery orders that are being picked can be shipped")
+	ErrShipmentDelivered = errors.New("Shipment has already been delivered")
+)
+
+// Shipment Struct - a package holding some of an order's items
+type Shipment struct {
+	ID             string          `json:"id" yaml:"id"`
+	Items          ShipmentItems   `json:"items" yaml:"items"`
+	Carrier        string          `json:"carrier" yaml:"carrier"`
+	TrackingNumber string          `json:"tracking_number" yaml:"tracking_number"`
+	Status         string          `json:"status" yaml:"status"`
+	Events         []TrackingEvent `json:"events" yaml:"events"`
+	CreatedAt      time.Time       `json:"created_at" yaml:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" yaml:"updated_at"`
+}
+
+// ShipmentItem Struct - a quantity of one product in a shipment
+type ShipmentItem struct {
+	ProductID string `json:"product_id" yaml:"product_id"`
+	Quantity  int    `json:"quantity" yaml:"quantity"`
+}
+
+// ShipmentItems Array
+type ShipmentItems []ShipmentItem
+
+// TrackingEvent Struct - a scan or status update reported by the carrier
+type TrackingEvent struct {
+	Status      string    `json:"status" yaml:"status"`
+	Timestamp   time.Time `json:"timestamp" yaml:"timestamp"`
+	Location    string    `json:"location,omitempty" yaml:"location,omitempty"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// ShipmentRequest Struct - request body for creating a shipment
+type ShipmentRequest struct {
+	Items          ShipmentItems `json:"items" yaml:"items"`
+	Carrier        string        `json:"carrier" yaml:"carrier"`
+	TrackingNumber string        `json:"tracking_number" yaml:"tracking_number"`
+}
+
+// FindShipment returns a pointer to the order's shipment with id
+func (o *Order) FindShipment(id string) (*Shipment, error) {
+	for i := range o.Shipments {
+		if o.Shipments[i].ID == id {
+			return &o.Shipments[i], nil
+		}
+	}
+	return nil, ErrShipmentNotFound
+}
+
+// unshippedQuantities returns how many of each product are not in a shipment yet
+func (o *Order) unshippedQuantities() map[string]int {
+	quantities := map[string]int{}
+	for _, item := range o.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	for _, s := range o.Shipments {
+		for _, item := range s.Items {
+			quantities[item.ProductID] -= item.Quantity
+		}
+	}
+	return quantities
+}
+
+// CreateShipment adds a shipment for some of the order's items. Only delivery
+// orders that are being picked can be shipped, and each product only up to
+// the quantity not already shipped.
+func (o *Order) CreateShipment(request ShipmentRequest, now time.Time) (Shipment, error) {
+	if o.Status != StatusPicking || o.DeliveryType == DeliveryTypeCollection {
+		return Shipment{}, ErrOrderNotShippable
+	}
+
+	verr := &ValidationError{}
+
+	if len(strings.TrimSpace(request.Carrier)) == 0 {
+		verr.Add("carrier", "is required")
+	}
+	if len(strings.TrimSpace(request.TrackingNumber)) == 0 {
+		verr.Add("tracking_number", "is required")
+	}
+	if len(request.Items) == 0 {
+		verr.Add("items", "must contain at least one item")
+	}
+
+	unshipped := o.unshippedQuantities()
+	for i, item := range request.Items {
+		field := "items[" + strconv.Itoa(i) + "]"
+		available, ordered := unshipped[item.ProductID]
+		switch {
+		case !ordered:
+			verr.Add(field+".product_id", "is not part of the order")
+		case item.Quantity <= 0:
+			verr.Add(field+".quantity", "must be greater than zero")
+		case item.Quantity > available:
+			verr.Add(field+".quantity", "must not exceed the "+strconv.Itoa(available)+" not yet shipped")
+		default:
+			unshipped[item.ProductID] -= item.Quantity
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		return Shipment{}, err
+	}
+
+	s := Shipment{
+		ID:             strconv.Itoa(len(o.Shipments) + 1),
+		Items:          append(ShipmentItems{}, request.Items...),
+		Carrier:        strings.TrimSpace(request.Carrier),
+		TrackingNumber: strings.TrimSpace(request.TrackingNumber),
+		Status:         ShipmentStatusCreated,
+		Events:         []TrackingEvent{},
+		CreatedAt:      now.UTC(),
+		UpdatedAt:      now.UTC(),
+	}
+	o.Shipments = append(o.Shipments, s)
+	o.updateFulfillment(now)
+	return s, nil
+}
+
+// AddTrackingEvent records a carrier event on the shipment. Events can arrive
+// out of order, so the shipment takes the status of its latest event.
+func (o *Order) AddTrackingEvent(shipmentID string, event TrackingEvent, now time.Time) (Shipment, error) {
+	s, err := o.FindShipment(shipmentID)
+	if err != nil {
+		return Shipment{}, err
+	}
+	if s.Status == ShipmentStatusDelivered {
+		return Shipment{}, ErrShipmentDelivered
+	}
+
+	event.Status = normalizeStatus(event.Status)
+	if !trackingStatuses[event.Status] {
+		return Shipment{}, &ValidationError{Errors: []FieldError{{Field: "status", Message: "must be one of IN_TRANSIT, OUT_FOR_DELIVERY, DELIVERED or EXCEPTION"}}}
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = now
+	}
+	event.Timestamp = event.Timestamp.UTC()
+
+	s.Events = append(s.Events, event)
+	sort.SliceStable(s.Events, func(i, j int) bool { return s.Events[i].Timestamp.Before(s.Events[j].Timestamp) })
+
+	// A delivery is final even if earlier scans are reported after it
+	if event.Status == ShipmentStatusDelivered {
+		s.Status = ShipmentStatusDelivered
+	} else {
+		s.Status = s.Events[len(s.Events)-1].Status
+	}
+	s.UpdatedAt = now.UTC()
+
+	updated := *s
+	o.updateFulfillment(now)
+	return updated, nil
+}
+
+// fulfillmentStatus derives the order's fulfillment status from its shipments
+func (o *Order) fulfillmentStatus() string {
+	if len(o.Shipments) == 0 {
+		return FulfillmentUnfulfilled
+	}
+
+	for _, quantity := range o.unshippedQuantities() {
+		if quantity > 0 {
+			return FulfillmentPartiallyShipped
+		}
+	}
+
+	for _, s := range o.Shipments {
+		if s.Status != ShipmentStatusDelivered {
+			return FulfillmentShipped
+		}
+	}
+	return FulfillmentDelivered
+}
+
+// updateFulfillment sets the fulfillment status and moves the order to
+// SHIPPED once every item has shipped, and to DELIVERED once every shipment
+// has been delivered
+func (o *Order) updateFulfillment(now time.Time) {
+	o.FulfillmentStatus = o.fulfillmentStatus()
+
+	if o.FulfillmentStatus == FulfillmentShipped || o.FulfillmentStatus == FulfillmentDelivered {
+		if o.Status == StatusPicking {
+			o.setStatus(StatusShipped, "system", "All items shipped", now)
+		}
+	}
+	if o.FulfillmentStatus == FulfillmentDelivered && o.Status == StatusShipped {
+		o.setStatus(StatusDelivered, "system", "All shipments delivered", now)
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/address"
+	"shared/money"
+)
+
+// Shipping rates file location passed via environment
+var shippingRatesFile = getEnvDefault("SHIPPING_RATES_FILE", "shipping-rates.json")
+
+var shippingRates = LoadShippingRates(shippingRatesFile)
+
+// Tier bases
+const (
+	TierBasisWeight = "weight"
+	TierBasisPrice  = "price"
+)
+
+// ShippingRates Struct - the contents of the shipping rates file. Products
+// don't have weights, so items weigh what their category is configured to
+// weigh, or DefaultWeight.
+type ShippingRates struct {
+	DefaultCountry  string             `json:"default_country"`
+	DefaultWeight   float64            `json:"default_weight"`
+	CategoryWeights map[string]float64 `json:"category_weights"`
+	Zones           []ShippingZone     `json:"zones"`
+	Methods         []ShippingMethod   `json:"methods"`
+}
+
+// ShippingZone Struct - a group of destinations. An address is in the first
+// zone whose country, states and zip code prefixes it matches; empty fields
+// match any address.
+type ShippingZone struct {
+	Name        string   `json:"name"`
+	Country     string   `json:"country,omitempty"`
+	States      []string `json:"states,omitempty"`
+	ZipPrefixes []string `json:"zip_prefixes,omitempty"`
+}
+
+// ShippingMethod Struct - a service offered by a carrier. Its cost comes from
+// the first tier the order's weight or discounted subtotal fits in, for the
+// destination zone. Orders whose discounted subtotal is at least
+// FreeShippingThreshold ship free.
+type ShippingMethod struct {
+	ID                    string             `json:"id"`
+	Carrier               string             `json:"carrier"`
+	Name                  string             `json:"name"`
+	TierBasis             string             `json:"tier_basis"`
+	FreeShippingThreshold float64            `json:"free_shipping_threshold,omitempty"`
+	Zones                 []ShippingZoneRate `json:"zones"`
+}
+
+// ShippingZoneRate Struct - a method's rates and transit time in one zone
+type ShippingZoneRate struct {
+	Zone    string     `json:"zone"`
+	Tiers   []RateTier `json:"tiers"`
+	MinDays int        `json:"min_days"`
+	MaxDays int        `json:"max_days"`
+}
+
+// RateTier Struct - the cost of orders up to a weight or subtotal. A tier
+// without a limit takes anything the tiers before it don't.
+type RateTier struct {
+	UpTo float64 `json:"up_to,omitempty"`
+	Cost float64 `json:"cost"`
+}
+
+// ShippingQuoteItem Struct - a cart item to quote shipping for
+type ShippingQuoteItem struct {
+	ProductID string `json:"product_id" yaml:"product_id"`
+	Quantity  int    `json:"quantity" yaml:"quantity"`
+}
+
+// ShippingQuoteRequest Struct - request body for quoting shipping
+type ShippingQuoteRequest struct {
+	Items          []ShippingQuoteItem `json:"items" yaml:"items"`
+	PromotionCodes []string            `json:"promotion_codes" yaml:"promotion_codes"`
+	Address        Address             `json:"address" yaml:"address"`
+}
+
+// ShippingOption Struct - a way to ship an order and what it costs
+type ShippingOption struct {
+	ID                        string  `json:"id" yaml:"id"`
+	Carrier                   string  `json:"carrier" yaml:"carrier"`
+	Name                      string  `json:"name" yaml:"name"`
+	Cost                      float32 `json:"cost" yaml:"cost"`
+	EstimatedDeliveryEarliest string  `json:"estimated_delivery_earliest" yaml:"estimated_delivery_earliest"`
+	EstimatedDeliveryLatest   string  `json:"estimated_delivery_latest" yaml:"estimated_delivery_latest"`
+}
+
+// ShippingQuote Struct - the shipping options for a cart and address
+type ShippingQuote struct {
+	Zone     string           `json:"zone" yaml:"zone"`
+	Weight   float64          `json:"weight" yaml:"weight"`
+	Subtotal float32          `json:"subtotal" yaml:"subtotal"`
+	Options  []ShippingOption `json:"options" yaml:"options"`
+}
+
+// ShippingSelection Struct - the shipping option chosen for an order. Only
+// OptionID is read when an order is created; the rest is filled in from a
+// new quote.
+type ShippingSelection struct {
+	OptionID                  string  `json:"option_id" yaml:"option_id"`
+	Carrier                   string  `json:"carrier" yaml:"carrier"`
+	Name                      string  `json:"name" yaml:"name"`
+	Cost                      float32 `json:"cost" yaml:"cost"`
+	EstimatedDeliveryEarliest string  `json:"estimated_delivery_earliest" yaml:"estimated_delivery_earliest"`
+	EstimatedDeliveryLatest   string  `json:"estimated_delivery_latest" yaml:"estimated_delivery_latest"`
+}
+
+// LoadShippingRates reads the rates file. Without one no shipping options
+// are offered.
+func LoadShippingRates(path string) *ShippingRates {
+	rates := &ShippingRates{}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Println("Unable to read shipping rates; no shipping options will be offered: ", err)
+		return rates
+	}
+	if err := json.Unmarshal(data, rates); err != nil {
+		log.Println("Unable to parse shipping rates; no shipping options will be offered: ", err)
+		return &ShippingRates{}
+	}
+
+	log.Println("Loaded shipping rates from: ", path)
+	return rates
+}
+
+// zoneFor returns the zone of an address, or "" when no zone covers it
+func (r *ShippingRates) zoneFor(address Address) string {
+	country := strings.TrimSpace(address.Country)
+	if len(country) == 0 {
+		country = r.DefaultCountry
+	}
+	state := strings.TrimSpace(address.State)
+	zip := strings.TrimSpace(address.ZipCode)
+
+	for _, zone := range r.Zones {
+		if len(zone.Country) > 0 && !strings.EqualFold(zone.Country, country) {
+			continue
+		}
+		if len(zone.States) > 0 && !containsFold(zone.States, state) {
+			continue
+		}
+		if len(zone.ZipPrefixes) > 0 && !hasAnyPrefix(zip, zone.ZipPrefixes) {
+			continue
+		}
+		return zone.Name
+	}
+	return ""
+}
+
+// weight returns how much an item of category weighs
+func (r *ShippingRates) weight(category string) float64 {
+	if w, ok := r.CategoryWeights[strings.ToLower(category)]; ok {
+		return w
+	}
+	return r.DefaultWeight
+}
+
+// Quote returns the options for shipping an order of the given total weight
+// and discounted subtotal to address, cheapest first
+func (r *ShippingRates) Quote(address Address, weight float64, subtotal float64, now time.Time) ShippingQuote {
+	quote := ShippingQuote{
+		Zone:     r.zoneFor(address),
+		Weight:   weight,
+		Subtotal: money.Round(subtotal),
+		Options:  []ShippingOption{},
+	}
+	if len(quote.Zone) == 0 {
+		return quote
+	}
+
+	for _, method := range r.Methods {
+		option, ok := method.quote(quote.Zone, weight, subtotal, now)
+		if ok {
+			quote.Options = append(quote.Options, option)
+		}
+	}
+
+	sort.SliceStable(quote.Options, func(i, j int) bool { return quote.Options[i].Cost < quote.Options[j].Cost })
+	return quote
+}
+
+// quote prices the method for an order, returning false if the method
+// doesn't serve the zone or the order exceeds its tiers
+func (m ShippingMethod) quote(zone string, weight float64, subtotal float64, now time.Time) (ShippingOption, bool) {
+	for _, rate := range m.Zones {
+		if rate.Zone != zone {
+			continue
+		}
+
+		measure := weight
+		if m.TierBasis == TierBasisPrice {
+			measure = subtotal
+		}
+
+		for _, tier := range rate.Tiers {
+			if tier.UpTo > 0 && measure > tier.UpTo {
+				continue
+			}
+
+			cost := tier.Cost
+			if m.FreeShippingThreshold > 0 && subtotal >= m.FreeShippingThreshold {
+				cost = 0
+			}
+			return ShippingOption{
+				ID:                        m.ID,
+				Carrier:                   m.Carrier,
+				Name:                      m.Name,
+				Cost:                      money.Round(cost),
+				EstimatedDeliveryEarliest: addBusinessDays(now, rate.MinDays).Format("2006-01-02"),
+				EstimatedDeliveryLatest:   addBusinessDays(now, rate.MaxDays).Format("2006-01-02"),
+			}, true
+		}
+	}
+	return ShippingOption{}, false
+}
+
+// QuoteItems looks up the items in the catalog and quotes shipping them to
+// destination. Discounts and free shipping are worked out from codes the way
+// PriceOrder works them out for an order.
+func QuoteItems(items []ShippingQuoteItem, codes []string, destination Address, catalog ProductCatalog, rates *ShippingRates) (ShippingQuote, error) {
+	destination = address.Normalize(destination)
+
+	verr := &ValidationError{}
+	if len(items) == 0 {
+		verr.Add("items", "must contain at least one item")
+	}
+	if len(destination.ZipCode) == 0 {
+		verr.Add("address.zipcode", "is required")
+	}
+
+	ids := make([]string, 0, len(items))
+	for i, item := range items {
+		field := "items[" + strconv.Itoa(i) + "]"
+		if len(strings.TrimSpace(item.ProductID)) == 0 {
+			verr.Add(field+".product_id", "is required")
+		}
+		if item.Quantity <= 0 {
+			verr.Add(field+".quantity", "must be greater than zero")
+		}
+		ids = append(ids, item.ProductID)
+	}
+	if err := verr.OrNil(); err != nil {
+		return ShippingQuote{}, err
+	}
+
+	products, err := catalog.FindProducts(ids)
+	if err != nil {
+		log.Println("QuoteItems error looking up products: ", err)
+		return ShippingQuote{}, ErrProductsUnavailable
+	}
+
+	now := time.Now()
+	var weight, subtotal float64
+	priced := make(OrderItems, len(items))
+	categories := make([]string, len(items))
+	for i, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			verr.Add("items["+strconv.Itoa(i)+"].product_id", "product does not exist")
+			continue
+		}
+		priced[i] = OrderItem{ProductID: item.ProductID, Price: product.Price, Quantity: item.Quantity}
+		categories[i] = product.Category
+		weight += rates.weight(product.Category) * float64(item.Quantity)
+		subtotal += float64(product.Price) * float64(item.Quantity)
+	}
+	found, _, err := findPromotions(verr, codes, now)
+	if err != nil {
+		return ShippingQuote{}, err
+	}
+	if err := verr.OrNil(); err != nil {
+		return ShippingQuote{}, err
+	}
+
+	discount, freeShipping := applyPromotions(priced, categories, found)
+	quote := rates.Quote(destination, weight, subtotal-discount, now)
+	if freeShipping {
+		for i := range quote.Options {
+			quote.Options[i].Cost = 0
+		}
+	}
+	return quote, nil
+}
+
+// selectShipping quotes the order's chosen shipping option again and fills in
+// its carrier, cost and delivery estimate. Orders with free shipping pay
+// nothing for the option; PriceOrder sets FreeShipping from the order's
+// promotion codes before calling it.
+func (o *Order) selectShipping(rates *ShippingRates, weight float64, subtotal float64, now time.Time) error {
+	if o.Shipping == nil {
+		return nil
+	}
+	if o.DeliveryType == DeliveryTypeCollection {
+		return &ValidationError{Errors: []FieldError{{Field: "shipping", Message: "is not available for collection orders"}}}
+	}
+
+	optionID := strings.TrimSpace(o.Shipping.OptionID)
+	if len(optionID) == 0 {
+		return &ValidationError{Errors: []FieldError{{Field: "shipping.option_id", Message: "is required"}}}
+	}
+
+	for _, option := range rates.Quote(o.ShippingAddress, weight, subtotal, now).Options {
+		if option.ID != optionID {
+			continue
+		}
+		if o.FreeShipping {
+			option.Cost = 0
+		}
+		o.Shipping = &ShippingSelection{
+			OptionID:                  option.ID,
+			Carrier:                   option.Carrier,
+			Name:                      option.Name,
+			Cost:                      option.Cost,
+			EstimatedDeliveryEarliest: option.EstimatedDeliveryEarliest,
+			EstimatedDeliveryLatest:   option.EstimatedDeliveryLatest,
+		}
+		return nil
+	}
+	return &ValidationError{Errors: []FieldError{{Field: "shipping.option_id", Message: "is not available for this order"}}}
+}
+
+// addBusinessDays returns the date days working days after t, skipping
+// weekends
+func addBusinessDays(t time.Time, days int) time.Time {
+	t = t.UTC()
+	for days > 0 {
+		t = t.AddDate(0, 0, 1)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			days--
+		}
+	}
+	return t
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
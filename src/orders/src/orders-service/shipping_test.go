@@ -0,0 +1,246 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// testRates are rates with one method of each tier basis
+func testRates() *ShippingRates {
+	return &ShippingRates{
+		DefaultCountry:  "US",
+		DefaultWeight:   2,
+		CategoryWeights: map[string]float64{"apparel": 1.5, "service": 0},
+		Zones: []ShippingZone{
+			{Name: "us-noncontiguous", Country: "US", States: []string{"AK", "HI"}},
+			{Name: "seattle", Country: "US", States: []string{"WA"}, ZipPrefixes: []string{"981"}},
+			{Name: "us-contiguous", Country: "US"},
+			{Name: "canada", Country: "CA"},
+		},
+		Methods: []ShippingMethod{
+			{
+				ID:                    "ground",
+				TierBasis:             TierBasisWeight,
+				FreeShippingThreshold: 50,
+				Zones: []ShippingZoneRate{
+					{Zone: "us-contiguous", MinDays: 2, MaxDays: 5, Tiers: []RateTier{{UpTo: 1, Cost: 5}, {UpTo: 5, Cost: 9}}},
+					{Zone: "seattle", MinDays: 1, MaxDays: 1, Tiers: []RateTier{{Cost: 3}}},
+				},
+			},
+			{
+				ID:        "express",
+				TierBasis: TierBasisPrice,
+				Zones: []ShippingZoneRate{
+					{Zone: "us-contiguous", MinDays: 1, MaxDays: 2, Tiers: []RateTier{{UpTo: 50, Cost: 20}, {Cost: 30}}},
+					{Zone: "us-noncontiguous", MinDays: 2, MaxDays: 3, Tiers: []RateTier{{Cost: 40}}},
+				},
+			},
+		},
+	}
+}
+
+func TestZoneFor(t *testing.T) {
+	rates := testRates()
+
+	tests := []struct {
+		name    string
+		address Address
+		want    string
+	}{
+		{name: "state list", address: Address{Country: "US", State: "HI", ZipCode: "96813"}, want: "us-noncontiguous"},
+		{name: "state and zip prefix", address: Address{Country: "US", State: "WA", ZipCode: "98101"}, want: "seattle"},
+		{name: "state outside zip prefix", address: Address{Country: "US", State: "WA", ZipCode: "99201"}, want: "us-contiguous"},
+		{name: "default country", address: Address{State: "OR", ZipCode: "97201"}, want: "us-contiguous"},
+		{name: "country ignores case", address: Address{Country: "ca", ZipCode: "K1A 0B1"}, want: "canada"},
+		{name: "not covered", address: Address{Country: "GB", ZipCode: "SW1A 1AA"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rates.zoneFor(tt.address); got != tt.want {
+				t.Errorf("zoneFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	rates := testRates()
+	// A Friday, so deliveries skip the weekend
+	now := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	oregon := Address{Country: "US", State: "OR", ZipCode: "97201"}
+
+	tests := []struct {
+		name      string
+		address   Address
+		weight    float64
+		subtotal  float64
+		wantCosts map[string]float32
+	}{
+		{
+			name:      "light order",
+			address:   oregon,
+			weight:    1,
+			subtotal:  20,
+			wantCosts: map[string]float32{"ground": 5, "express": 20},
+		},
+		{
+			name:      "heavier weight tier",
+			address:   oregon,
+			weight:    3,
+			subtotal:  20,
+			wantCosts: map[string]float32{"ground": 9, "express": 20},
+		},
+		{
+			name:      "too heavy for the weight tiers",
+			address:   oregon,
+			weight:    6,
+			subtotal:  20,
+			wantCosts: map[string]float32{"express": 20},
+		},
+		{
+			name:      "free shipping threshold and unlimited price tier",
+			address:   oregon,
+			weight:    1,
+			subtotal:  60,
+			wantCosts: map[string]float32{"ground": 0, "express": 30},
+		},
+		{
+			name:      "zone served by one method",
+			address:   Address{Country: "US", State: "AK", ZipCode: "99501"},
+			weight:    1,
+			subtotal:  20,
+			wantCosts: map[string]float32{"express": 40},
+		},
+		{
+			name:      "zone served by no method",
+			address:   Address{Country: "CA", ZipCode: "K1A 0B1"},
+			weight:    1,
+			subtotal:  20,
+			wantCosts: map[string]float32{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := rates.Quote(tt.address, tt.weight, tt.subtotal, now)
+			if len(quote.Options) != len(tt.wantCosts) {
+				t.Fatalf("Options = %+v, want costs %v", quote.Options, tt.wantCosts)
+			}
+			for i, option := range quote.Options {
+				if want, ok := tt.wantCosts[option.ID]; !ok || option.Cost != want {
+					t.Errorf("%s cost = %v, want %v", option.ID, option.Cost, tt.wantCosts[option.ID])
+				}
+				if i > 0 && quote.Options[i-1].Cost > option.Cost {
+					t.Errorf("Options aren't sorted cheapest first: %+v", quote.Options)
+				}
+			}
+		})
+	}
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	friday := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		days int
+		want string
+	}{
+		{days: 0, want: "2024-06-07"},
+		{days: 1, want: "2024-06-10"},
+		{days: 5, want: "2024-06-14"},
+		{days: 6, want: "2024-06-17"},
+	}
+
+	for _, tt := range tests {
+		if got := addBusinessDays(friday, tt.days).Format("2006-01-02"); got != tt.want {
+			t.Errorf("addBusinessDays(%d) = %s, want %s", tt.days, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteItems(t *testing.T) {
+	rates := testRates()
+	oregon := Address{Country: "US", State: "OR", ZipCode: "97201"}
+
+	tests := []struct {
+		name         string
+		items        []ShippingQuoteItem
+		codes        []string
+		wantSubtotal float32
+		wantCosts    map[string]float32
+		wantFields   []string
+	}{
+		{
+			name:         "priced from the catalog",
+			items:        []ShippingQuoteItem{{ProductID: "shirt", Quantity: 2}},
+			wantSubtotal: 40,
+			wantCosts:    map[string]float32{"ground": 9, "express": 20},
+		},
+		{
+			name:         "discounts count towards tiers",
+			items:        []ShippingQuoteItem{{ProductID: "shirt", Quantity: 3}},
+			codes:        []string{"SAVE5"},
+			wantSubtotal: 55,
+			wantCosts:    map[string]float32{"ground": 0, "express": 30},
+		},
+		{
+			name:         "free shipping code",
+			items:        []ShippingQuoteItem{{ProductID: "shirt", Quantity: 1}},
+			codes:        []string{"freeship"},
+			wantSubtotal: 20,
+			wantCosts:    map[string]float32{"ground": 0, "express": 0},
+		},
+		{
+			name:       "unknown code",
+			items:      []ShippingQuoteItem{{ProductID: "shirt", Quantity: 1}},
+			codes:      []string{"NOPE"},
+			wantFields: []string{"promotion_codes[0]"},
+		},
+		{
+			name:       "invalid items",
+			items:      []ShippingQuoteItem{{ProductID: "", Quantity: 0}},
+			wantFields: []string{"items[0].product_id", "items[0].quantity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := QuoteItems(tt.items, tt.codes, oregon, newFakeCatalog(), rates)
+
+			if len(tt.wantFields) > 0 {
+				verr, ok := err.(*ValidationError)
+				if !ok {
+					t.Fatalf("QuoteItems() error = %v, want a validation error", err)
+				}
+				if len(verr.Errors) != len(tt.wantFields) {
+					t.Fatalf("errors = %+v, want fields %v", verr.Errors, tt.wantFields)
+				}
+				for i, field := range tt.wantFields {
+					if verr.Errors[i].Field != field {
+						t.Errorf("errors[%d].Field = %q, want %q", i, verr.Errors[i].Field, field)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("QuoteItems() error = %v", err)
+			}
+
+			if quote.Subtotal != tt.wantSubtotal {
+				t.Errorf("Subtotal = %v, want %v", quote.Subtotal, tt.wantSubtotal)
+			}
+			if len(quote.Options) != len(tt.wantCosts) {
+				t.Fatalf("Options = %+v, want costs %v", quote.Options, tt.wantCosts)
+			}
+			for _, option := range quote.Options {
+				if option.Cost != tt.wantCosts[option.ID] {
+					t.Errorf("%s cost = %v, want %v", option.ID, option.Cost, tt.wantCosts[option.ID])
+				}
+			}
+		})
+	}
+}
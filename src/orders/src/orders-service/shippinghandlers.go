@@ -0,0 +1,38 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ShippingQuoteCreate Handler
+// Quotes the shipping options for a cart's items and a delivery address. The
+// ID of the option chosen is passed as shipping.option_id when the order is
+// created, which quotes it again.
+func ShippingQuoteCreate(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if (*r).Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var request ShippingQuoteRequest
+	if !readJSONBody(w, r, &request) {
+		return
+	}
+
+	quote, err := QuoteItems(request.Items, request.PromotionCodes, request.Address, productCatalog, shippingRates)
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(quote); err != nil {
+		panic(err)
+	}
+}
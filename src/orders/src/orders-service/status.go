@@ -0,0 +1,157 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Order statuses
+const (
+	StatusPending            = "PENDING"
+	StatusPaid               = "PAID"
+	StatusPicking            = "PICKING"
+	StatusReadyForCollection = "READY_FOR_COLLECTION"
+	StatusShipped            = "SHIPPED"
+	StatusDelivered          = "DELIVERED"
+	StatusCancelled          = "CANCELLED"
+)
+
+// Delivery types
+const (
+	DeliveryTypeDelivery   = "DELIVERY"
+	DeliveryTypeCollection = "COLLECTION"
+)
+
+// deliveryStatusComplete is the delivery_status clients such as the web UI
+// and the location Lambda functions use for completed orders
+const deliveryStatusComplete = "COMPLETE"
+
+// transitions lists the statuses an order can move to from each status.
+// DELIVERED and CANCELLED are final.
+var transitions = map[string][]string{
+	StatusPending:            {StatusPaid, StatusCancelled},
+	StatusPaid:               {StatusPicking, StatusCancelled},
+	StatusPicking:            {StatusReadyForCollection, StatusShipped, StatusCancelled},
+	StatusReadyForCollection: {StatusDelivered, StatusCancelled},
+	StatusShipped:            {StatusDelivered},
+	StatusDelivered:          {},
+	StatusCancelled:          {},
+}
+
+// ErrOrderNotFound is returned when no order exists for an ID
+var ErrOrderNotFound = errors.New("Order not found")
+
+// ErrUnknownStatus is returned for a status that is not part of the lifecycle
+var ErrUnknownStatus = errors.New("Unknown order status")
+
+// TransitionError is returned when an order can't move to the requested status
+type TransitionError struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Allowed []string `json:"allowed"`
+	Message string   `json:"message"`
+}
+
+func (e *TransitionError) Error() string {
+	return e.Message
+}
+
+// StatusChange Struct - an entry in an order's status history
+type StatusChange struct {
+	From      string    `json:"from" yaml:"from"`
+	To        string    `json:"to" yaml:"to"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	Actor     string    `json:"actor" yaml:"actor"`
+	Reason    string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// TransitionRequest Struct - request body for changing an order's status
+type TransitionRequest struct {
+	Status string `json:"status" yaml:"status"`
+	Actor  string `json:"actor" yaml:"actor"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// allowedTransitions returns the statuses the order can move to next, taking
+// its delivery type into account. Orders can't be cancelled once anything has
+// shipped.
+func allowedTransitions(order Order) []string {
+	allowed := []string{}
+	for _, status := range transitions[order.Status] {
+		if status == StatusReadyForCollection && order.DeliveryType != DeliveryTypeCollection {
+			continue
+		}
+		if status == StatusShipped && order.DeliveryType == DeliveryTypeCollection {
+			continue
+		}
+		if status == StatusCancelled && len(order.Shipments) > 0 {
+			continue
+		}
+		allowed = append(allowed, status)
+	}
+	return allowed
+}
+
+// Transition moves the order to status and records the change in its
+// history. Only the transitions returned by allowedTransitions are accepted.
+func (o *Order) Transition(status string, actor string, reason string, now time.Time) error {
+	status = normalizeStatus(status)
+	if _, ok := transitions[status]; !ok {
+		return ErrUnknownStatus
+	}
+
+	allowed := allowedTransitions(*o)
+	legal := false
+	for _, s := range allowed {
+		if s == status {
+			legal = true
+			break
+		}
+	}
+	if !legal {
+		return &TransitionError{
+			From:    o.Status,
+			To:      status,
+			Allowed: allowed,
+			Message: "Order cannot move from " + o.Status + " to " + status,
+		}
+	}
+
+	o.setStatus(status, actor, reason, now)
+	return nil
+}
+
+// setStatus records the change to status without checking that it is legal
+func (o *Order) setStatus(status string, actor string, reason string, now time.Time) {
+	if len(actor) == 0 {
+		actor = "system"
+	}
+
+	change := StatusChange{
+		From:      o.Status,
+		To:        status,
+		Timestamp: now.UTC(),
+		Actor:     actor,
+		Reason:    reason,
+	}
+	o.History = append(o.History, change)
+	o.Status = status
+	o.recordStatusEvents(change)
+
+	// Keep the legacy delivery fields in step for existing clients
+	o.DeliveryComplete = status == StatusDelivered
+	if o.DeliveryComplete {
+		o.DeliveryStatus = deliveryStatusComplete
+	} else {
+		o.DeliveryStatus = status
+	}
+}
+
+// normalizeStatus puts a status supplied by a client in canonical form
+func normalizeStatus(status string) string {
+	return strings.ToUpper(strings.TrimSpace(status))
+}
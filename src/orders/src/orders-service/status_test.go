@@ -0,0 +1,68 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransition(t *testing.T) {
+	now := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		order        Order
+		status       string
+		wantErr      bool
+		wantDelivery string
+	}{
+		{name: "pending to paid", order: Order{Status: StatusPending}, status: "paid", wantDelivery: StatusPaid},
+		{name: "pending can't skip to shipped", order: Order{Status: StatusPending}, status: StatusShipped, wantErr: true},
+		{name: "delivery orders ship", order: Order{Status: StatusPicking, DeliveryType: DeliveryTypeDelivery}, status: StatusShipped, wantDelivery: StatusShipped},
+		{name: "collection orders don't ship", order: Order{Status: StatusPicking, DeliveryType: DeliveryTypeCollection}, status: StatusShipped, wantErr: true},
+		{name: "collection orders are collected", order: Order{Status: StatusPicking, DeliveryType: DeliveryTypeCollection}, status: StatusReadyForCollection, wantDelivery: StatusReadyForCollection},
+		{name: "delivered is complete", order: Order{Status: StatusShipped, DeliveryType: DeliveryTypeDelivery}, status: StatusDelivered, wantDelivery: deliveryStatusComplete},
+		{name: "picking can be cancelled", order: Order{Status: StatusPicking}, status: StatusCancelled, wantDelivery: StatusCancelled},
+		{name: "shipped can't be cancelled", order: Order{Status: StatusShipped}, status: StatusCancelled, wantErr: true},
+		{name: "orders with shipments can't be cancelled", order: Order{Status: StatusPicking, Shipments: []Shipment{{}}}, status: StatusCancelled, wantErr: true},
+		{name: "cancelled is final", order: Order{Status: StatusCancelled}, status: StatusPaid, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			err := order.Transition(tt.status, "tester", "", now)
+			if tt.wantErr {
+				if _, ok := err.(*TransitionError); !ok {
+					t.Fatalf("Transition() error = %v, want a transition error", err)
+				}
+				if order.Status != tt.order.Status || len(order.History) != 0 {
+					t.Errorf("order changed by a rejected transition: %+v", order)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transition() error = %v", err)
+			}
+
+			if order.Status != normalizeStatus(tt.status) {
+				t.Errorf("Status = %q, want %q", order.Status, normalizeStatus(tt.status))
+			}
+			if order.DeliveryStatus != tt.wantDelivery {
+				t.Errorf("DeliveryStatus = %q, want %q", order.DeliveryStatus, tt.wantDelivery)
+			}
+			if len(order.History) != 1 || order.History[0].From != tt.order.Status || order.History[0].Actor != "tester" {
+				t.Errorf("History = %+v, want one change from %q by tester", order.History, tt.order.Status)
+			}
+		})
+	}
+}
+
+func TestTransitionUnknownStatus(t *testing.T) {
+	order := Order{Status: StatusPending}
+	if err := order.Transition("LOST", "", "", time.Now()); err != ErrUnknownStatus {
+		t.Errorf("Transition() error = %v, want %v", err, ErrUnknownStatus)
+	}
+}
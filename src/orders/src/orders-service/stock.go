@@ -0,0 +1,81 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"log"
+)
+
+// StockShortage Struct - an item that can't be fulfilled from current stock
+type StockShortage struct {
+	ProductID string `json:"product_id"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// OutOfStockError is returned when an order's stock can't be taken
+type OutOfStockError struct {
+	Items []StockShortage `json:"items"`
+}
+
+func (e *OutOfStockError) Error() string {
+	return "Insufficient stock for one or more items"
+}
+
+// takeStock decrements inventory for the order's items through the products
+// service reservation held under reservationID: the ID of the cart being
+// checked out, or a reservation of the order's own. Each product's
+// reservation is first set to the order's quantity, so the stock taken always
+// matches the order, and then committed. If any product fails, its
+// reservation is released and the stock already taken is added back.
+func takeStock(reservationID string, items OrderItems) error {
+	taken := ReturnItems{}
+	for _, item := range stockItems(items) {
+		err := productCatalog.Reserve(item.ProductID, reservationID, item.Quantity)
+		if err == nil {
+			err = productCatalog.CommitReservation(item.ProductID, reservationID)
+			if err != nil {
+				releaseReservation(item.ProductID, reservationID)
+			}
+		}
+		if err != nil {
+			log.Println("takeStock error: ", reservationID, item.ProductID, err)
+			restockItems(taken)
+			if _, ok := err.(*OutOfStockError); ok {
+				return err
+			}
+			return ErrInventoryUnavailable
+		}
+		taken = append(taken, item)
+	}
+	return nil
+}
+
+// releaseReservation gives back the stock held for a product by a reservation
+// that could not be committed. Failures are only logged; the reservation
+// expires by itself.
+func releaseReservation(productID string, reservationID string) {
+	if err := productCatalog.Reserve(productID, reservationID, 0); err != nil {
+		log.Println("releaseReservation error: ", reservationID, productID, err)
+	}
+}
+
+// stockItems returns the products and quantities of items for taking or
+// restocking inventory, one entry per product
+func stockItems(items OrderItems) ReturnItems {
+	stock := ReturnItems{}
+	index := map[string]int{}
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		if i, ok := index[item.ProductID]; ok {
+			stock[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(stock)
+		stock = append(stock, ReturnItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return stock
+}
@@ -0,0 +1,50 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+)
+
+// ErrOrderVersionConflict is returned by an OrderRepository when an update is
+// based on a version of the order that is no longer current
+var ErrOrderVersionConflict = errors.New("Order has been modified since it was read")
+
+// OrderRepository persists orders. The repository functions delegate to the
+// implementation selected at startup so handlers don't need to know which
+// backend is in use. Implementations must be safe for concurrent use and
+// stamp every write with a new Version.
+type OrderRepository interface {
+	// FindAll returns every order
+	FindAll() (Orders, error)
+	// FindByID returns the order for id or ErrOrderNotFound
+	FindByID(id string) (Order, error)
+	// FindByUsername returns the orders belonging to username
+	FindByUsername(username string) (Orders, error)
+	// FindByStatus returns the orders currently in status
+	FindByStatus(status string) (Orders, error)
+	// Query returns a page of the orders matching q, reading no more of the
+	// store than the page needs where the store allows it
+	Query(q OrderQuery) (OrderPage, error)
+	// FindWithPendingEvents returns the orders with events in their outbox
+	FindWithPendingEvents() (Orders, error)
+	// Create assigns a new ID and the first version to order and persists it
+	Create(order Order) (Order, error)
+	// Update replaces an existing order if order.Version is still the stored
+	// version, otherwise it returns ErrOrderVersionConflict
+	Update(order Order) (Order, error)
+}
+
+// NewOrderRepository returns a DynamoDB backed repository when an orders
+// table is configured, otherwise an in-memory repository.
+func NewOrderRepository() OrderRepository {
+	if len(ddbTableOrders) > 0 {
+		log.Println("Using DynamoDB order repository with table: ", ddbTableOrders)
+		return NewDynamoOrderRepository(dynamoClient, ddbTableOrders)
+	}
+
+	log.Println("Using in-memory order repository")
+	return NewMemoryOrderRepository()
+}
@@ -0,0 +1,169 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/address"
+	"shared/money"
+	"shared/tax"
+	"shared/validation"
+)
+
+// ErrProductsUnavailable is returned when an order can't be priced because the
+// products service could not be reached
+var ErrProductsUnavailable = errors.New("Unable to look up product prices")
+
+// FieldError Struct - a problem with one field of a request
+type FieldError = validation.FieldError
+
+// ValidationError is returned when a request has one or more invalid fields
+type ValidationError = validation.Error
+
+// Validate checks the fields a client supplies when creating an order and
+// normalizes the delivery type and addresses. Delivery orders need complete
+// billing and shipping addresses; collection orders need a phone number, and
+// their billing address is checked only when one is given.
+func (o *Order) Validate() error {
+	verr := &ValidationError{}
+
+	if len(o.Items) == 0 {
+		verr.Add("items", "must contain at least one item")
+	}
+	for i, item := range o.Items {
+		field := "items[" + strconv.Itoa(i) + "]"
+		if len(strings.TrimSpace(item.ProductID)) == 0 {
+			verr.Add(field+".product_id", "is required")
+		}
+		if item.Quantity <= 0 {
+			verr.Add(field+".quantity", "must be greater than zero")
+		}
+	}
+
+	o.DeliveryType = strings.ToUpper(strings.TrimSpace(o.DeliveryType))
+	if len(o.DeliveryType) == 0 {
+		o.DeliveryType = DeliveryTypeDelivery
+	}
+
+	switch o.DeliveryType {
+	case DeliveryTypeDelivery:
+		o.BillingAddress = validateAddress(verr, "billing_address", o.BillingAddress)
+		o.ShippingAddress = validateAddress(verr, "shipping_address", o.ShippingAddress)
+	case DeliveryTypeCollection:
+		if len(strings.TrimSpace(o.CollectionPhone)) == 0 {
+			verr.Add("collection_phone", "is required for collection orders")
+		}
+		if o.BillingAddress != (Address{}) {
+			o.BillingAddress = validateAddress(verr, "billing_address", o.BillingAddress)
+		}
+	default:
+		verr.Add("delivery_type", "must be DELIVERY or COLLECTION")
+	}
+
+	return verr.OrNil()
+}
+
+// validateAddress normalizes the address and checks it has the fields needed
+// to bill or ship to it in its country
+func validateAddress(verr *ValidationError, field string, a Address) Address {
+	a = address.Normalize(a)
+	verr.AddAll(field, address.Validate(a))
+	return a
+}
+
+// PriceOrder sets each item's name and price from the products service and
+// recomputes the order's subtotal, discount, tax and total, so the amounts a
+// client posts are never trusted. Line discounts and free shipping come from
+// the order's promotion codes, applied the way the carts service applies
+// them; unknown or expired codes are rejected. Tax is charged on
+// the discounted amounts at the shipping address, or the billing address for
+// collection orders. The chosen shipping option is priced from rates and added
+// to the total untaxed.
+func PriceOrder(order *Order, catalog ProductCatalog, taxes *tax.Calculator, rates *ShippingRates) error {
+	ids := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := catalog.FindProducts(ids)
+	if err != nil {
+		log.Println("PriceOrder error looking up products: ", err)
+		return ErrProductsUnavailable
+	}
+
+	now := time.Now()
+	verr := &ValidationError{}
+	var subtotal, weight float64
+	categories := make([]string, len(order.Items))
+	for i := range order.Items {
+		item := &order.Items[i]
+
+		product, ok := products[item.ProductID]
+		if !ok {
+			verr.Add("items["+strconv.Itoa(i)+"].product_id", "product does not exist")
+			continue
+		}
+
+		item.ProductName = product.Name
+		item.Price = product.Price
+		categories[i] = product.Category
+
+		subtotal += float64(product.Price) * float64(item.Quantity)
+		weight += rates.weight(product.Category) * float64(item.Quantity)
+	}
+	found, codes, err := findPromotions(verr, order.PromotionCodes, now)
+	if err != nil {
+		return err
+	}
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	order.PromotionCodes = codes
+	discount, freeShipping := applyPromotions(order.Items, categories, found)
+	order.FreeShipping = freeShipping
+
+	taxable := make([]tax.Item, len(order.Items))
+	for i, item := range order.Items {
+		amount := float64(item.Price)*float64(item.Quantity) - float64(item.Discount)
+		taxable[i] = tax.Item{Category: categories[i], Amount: amount}
+	}
+
+	if err := order.selectShipping(rates, weight, subtotal-discount, now); err != nil {
+		return err
+	}
+
+	taxed := taxes.Calculate(order.taxAddress(), taxable)
+	for i := range order.Items {
+		order.Items[i].Tax = money.Round(taxed.ItemTax[i])
+	}
+
+	order.Subtotal = money.Round(subtotal)
+	order.Discount = money.Round(discount)
+	order.Tax = taxed.Total
+	order.TaxLines = taxed.Lines
+	order.Total = money.Round(subtotal - discount + float64(taxed.Total) + float64(order.shippingCost()))
+	return nil
+}
+
+// shippingCost returns what the order pays for shipping
+func (o *Order) shippingCost() float32 {
+	if o.Shipping == nil {
+		return 0
+	}
+	return o.Shipping.Cost
+}
+
+// taxAddress returns the address the order is taxed at
+func (o *Order) taxAddress() Address {
+	if o.DeliveryType == DeliveryTypeCollection {
+		return o.BillingAddress
+	}
+	return o.ShippingAddress
+}
@@ -0,0 +1,279 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shared/tax"
+)
+
+// fakeCatalog is a ProductCatalog backed by a map. Stock taken and added back
+// is recorded in deltas, and products in stock can't be reserved beyond it.
+// Committing the reservation of commitFails returns an error.
+type fakeCatalog struct {
+	products    map[string]CatalogProduct
+	stock       map[string]int
+	reserved    map[string]int
+	deltas      map[string]int
+	err         error
+	commitFails string
+}
+
+func newFakeCatalog() *fakeCatalog {
+	return &fakeCatalog{
+		products: map[string]CatalogProduct{
+			"shirt": {ID: "shirt", Name: "Shirt", Category: "apparel", Price: 20},
+			"apple": {ID: "apple", Name: "Apple", Category: "groceries", Price: 1.25},
+		},
+		stock:    map[string]int{},
+		reserved: map[string]int{},
+		deltas:   map[string]int{},
+	}
+}
+
+func (c *fakeCatalog) FindProducts(ids []string) (map[string]CatalogProduct, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	found := map[string]CatalogProduct{}
+	for _, id := range ids {
+		if product, ok := c.products[id]; ok {
+			found[id] = product
+		}
+	}
+	return found, nil
+}
+
+func (c *fakeCatalog) UpdateInventory(productID string, stockDelta int) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.deltas[productID] += stockDelta
+	return nil
+}
+
+func (c *fakeCatalog) Reserve(productID string, reservationID string, quantity int) error {
+	if c.err != nil {
+		return c.err
+	}
+	if available, ok := c.stock[productID]; ok && quantity > available {
+		return &OutOfStockError{Items: []StockShortage{{ProductID: productID, Requested: quantity, Available: available}}}
+	}
+	c.reserved[productID+"/"+reservationID] = quantity
+	return nil
+}
+
+func (c *fakeCatalog) CommitReservation(productID string, reservationID string) error {
+	if c.err != nil {
+		return c.err
+	}
+	if productID == c.commitFails {
+		return errors.New("commit failed")
+	}
+	key := productID + "/" + reservationID
+	c.deltas[productID] -= c.reserved[key]
+	delete(c.reserved, key)
+	return nil
+}
+
+func TestPriceOrder(t *testing.T) {
+	noTax := tax.NewCalculator(filepath.Join(os.TempDir(), "missing-tax-rules.json"))
+	rates := LoadShippingRates("shipping-rates.json")
+	home := Address{Country: "US", State: "WA", ZipCode: "98101"}
+
+	tests := []struct {
+		name         string
+		order        Order
+		wantSubtotal float32
+		wantDiscount float32
+		wantShipping float32
+		wantTotal    float32
+		wantCodes    []string
+		wantFields   []string
+	}{
+		{
+			name: "prices come from the catalog",
+			order: Order{
+				Items: OrderItems{{ProductID: "shirt", Quantity: 2, Price: 1, Discount: 40}},
+			},
+			wantSubtotal: 40,
+			wantTotal:    40,
+			wantCodes:    []string{},
+		},
+		{
+			name: "client discounts are ignored",
+			order: Order{
+				Items: OrderItems{{ProductID: "shirt", Quantity: 1, Discount: 15}},
+			},
+			wantSubtotal: 20,
+			wantTotal:    20,
+			wantCodes:    []string{},
+		},
+		{
+			name: "promotion codes are applied once",
+			order: Order{
+				Items:          OrderItems{{ProductID: "shirt", Quantity: 1}, {ProductID: "apple", Quantity: 4}},
+				PromotionCodes: []string{" welcome10", "WELCOME10"},
+			},
+			wantSubtotal: 25,
+			wantDiscount: 2.5,
+			wantTotal:    22.5,
+			wantCodes:    []string{"WELCOME10"},
+		},
+		{
+			name: "free shipping from a code",
+			order: Order{
+				Items:           OrderItems{{ProductID: "shirt", Quantity: 1}},
+				DeliveryType:    DeliveryTypeDelivery,
+				ShippingAddress: home,
+				Shipping:        &ShippingSelection{OptionID: "ups-ground"},
+				PromotionCodes:  []string{"FREESHIP"},
+			},
+			wantSubtotal: 20,
+			wantTotal:    20,
+			wantCodes:    []string{"FREESHIP"},
+		},
+		{
+			name: "free shipping flag from the client is ignored",
+			order: Order{
+				Items:           OrderItems{{ProductID: "shirt", Quantity: 1}},
+				DeliveryType:    DeliveryTypeDelivery,
+				ShippingAddress: home,
+				Shipping:        &ShippingSelection{OptionID: "ups-ground"},
+				FreeShipping:    true,
+			},
+			wantSubtotal: 20,
+			wantShipping: 9.99,
+			wantTotal:    29.99,
+			wantCodes:    []string{},
+		},
+		{
+			name: "unknown code and product",
+			order: Order{
+				Items:          OrderItems{{ProductID: "missing", Quantity: 1}},
+				PromotionCodes: []string{"NOPE"},
+			},
+			wantFields: []string{"items[0].product_id", "promotion_codes[0]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			err := PriceOrder(&order, newFakeCatalog(), noTax, rates)
+
+			if len(tt.wantFields) > 0 {
+				verr, ok := err.(*ValidationError)
+				if !ok {
+					t.Fatalf("PriceOrder() error = %v, want a validation error", err)
+				}
+				if len(verr.Errors) != len(tt.wantFields) {
+					t.Fatalf("errors = %+v, want fields %v", verr.Errors, tt.wantFields)
+				}
+				for i, field := range tt.wantFields {
+					if verr.Errors[i].Field != field {
+						t.Errorf("errors[%d].Field = %q, want %q", i, verr.Errors[i].Field, field)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PriceOrder() error = %v", err)
+			}
+
+			if order.Subtotal != tt.wantSubtotal || order.Discount != tt.wantDiscount || order.Total != tt.wantTotal {
+				t.Errorf("subtotal, discount, total = %v, %v, %v, want %v, %v, %v",
+					order.Subtotal, order.Discount, order.Total, tt.wantSubtotal, tt.wantDiscount, tt.wantTotal)
+			}
+			if got := order.shippingCost(); got != tt.wantShipping {
+				t.Errorf("shipping cost = %v, want %v", got, tt.wantShipping)
+			}
+			if len(order.PromotionCodes) != len(tt.wantCodes) {
+				t.Fatalf("PromotionCodes = %v, want %v", order.PromotionCodes, tt.wantCodes)
+			}
+			for i := range tt.wantCodes {
+				if order.PromotionCodes[i] != tt.wantCodes[i] {
+					t.Errorf("PromotionCodes = %v, want %v", order.PromotionCodes, tt.wantCodes)
+				}
+			}
+		})
+	}
+}
+
+func TestPriceOrderCatalogUnavailable(t *testing.T) {
+	catalog := newFakeCatalog()
+	catalog.err = errors.New("connection refused")
+	order := Order{Items: OrderItems{{ProductID: "shirt", Quantity: 1}}}
+
+	if err := PriceOrder(&order, catalog, taxCalculator, shippingRates); err != ErrProductsUnavailable {
+		t.Errorf("PriceOrder() error = %v, want %v", err, ErrProductsUnavailable)
+	}
+}
+
+func TestUpdateOrderKeepsPricedFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(o *Order)
+		wantFields []string
+	}{
+		{name: "unchanged", change: func(o *Order) {}},
+		{name: "same address before normalizing", change: func(o *Order) { o.ShippingAddress.Country = " usa " }},
+		{name: "collection phone", change: func(o *Order) { o.CollectionPhone = "555-0100" }},
+		{name: "username", change: func(o *Order) { o.Username = "someone else" }, wantFields: []string{"username"}},
+		{name: "delivery type", change: func(o *Order) { o.DeliveryType = "collection" }, wantFields: []string{"delivery_type"}},
+		{
+			name: "addresses",
+			change: func(o *Order) {
+				o.BillingAddress.City = "Portland"
+				o.ShippingAddress.ZipCode = "97201"
+			},
+			wantFields: []string{"billing_address", "shipping_address"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, restore := useFakeCatalog()
+			defer restore()
+
+			created, err := RepoCreateOrder(newTestOrder(""))
+			if err != nil {
+				t.Fatalf("RepoCreateOrder() error = %v", err)
+			}
+			update := created
+			tt.change(&update)
+
+			updated, err := RepoUpdateOrder(update)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("RepoUpdateOrder() error = %v", err)
+				}
+				if updated.Version == created.Version {
+					t.Errorf("Version = %d, want the update stored", updated.Version)
+				}
+				return
+			}
+
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("RepoUpdateOrder() error = %v, want a validation error", err)
+			}
+			if len(verr.Errors) != len(tt.wantFields) {
+				t.Fatalf("errors = %+v, want fields %v", verr.Errors, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if verr.Errors[i].Field != field {
+					t.Errorf("errors[%d].Field = %q, want %q", i, verr.Errors[i].Field, field)
+				}
+			}
+			if stored := RepoFindOrderByID(created.ID); stored.Version != created.Version {
+				t.Errorf("stored Version = %d, want the order unchanged", stored.Version)
+			}
+		})
+	}
+}
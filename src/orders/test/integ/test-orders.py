@@ -62,6 +62,25 @@ def test_post_orders():
     integhelpers.post_request_assert(orders_api_url, endpoint, request_bodies_path, schemas_path)
 
 
+def test_post_orders_id_transitions(created_order):
+    endpoint = f"/orders/id/{created_order['id']}/transitions"
+    url = integhelpers.full_request_url(orders_api_url, endpoint)
+    headers = {"Content-Type": "application/json", "Accept": "application/json"}
+
+    assert created_order['status'] == "PENDING"
+
+    response = requests.post(url, data=json.dumps({"status": "DELIVERED"}), headers=headers)
+    assert response.status_code == 409
+
+    response = requests.post(url, data=json.dumps({"status": "PAID", "actor": "integ-test"}), headers=headers)
+    assert response.status_code == 200
+    assert json.loads(response.text)['status'] == "PAID"
+
+    history = json.loads(requests.get(url).text)
+    assert [change['to'] for change in history] == ["PENDING", "PAID"]
+    assert history[-1]['actor'] == "integ-test"
+
+
 def test_get_orders_username():
     endpoint = "/orders/username/:username"
     params = {":username": test_username}
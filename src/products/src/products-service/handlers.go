@@ -17,6 +17,7 @@ import (
 
 	"strconv"
 	"strings"
+	"time"
 )
 
 var imageRootURL = os.Getenv("IMAGE_ROOT_URL")
@@ -257,7 +258,14 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := RepoUpdateProduct(&existingProduct, &product); err != nil {
-		http.Error(w, "Internal error updating product", http.StatusInternalServerError)
+		switch err {
+		case ErrProductNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errReservationConflict:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Internal error updating product", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -313,6 +321,84 @@ func UpdateInventory(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ReserveInventory - sets the quantity of a product held by a reservation
+func ReserveInventory(w http.ResponseWriter, r *http.Request) {
+	initResponse(&w)
+
+	vars := mux.Vars(r)
+
+	var request ReservationRequest
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		http.Error(w, "Unable to read request body", http.StatusBadRequest)
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		log.Println("ReserveInventory error closing request body: ", err)
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusUnprocessableEntity)
+		return
+	}
+
+	product, err := RepoReserveStock(vars["productID"], vars["reservationID"], request.Quantity, time.Duration(request.TTLSeconds)*time.Second)
+	writeReservationResult(w, r, product, err)
+}
+
+// ReleaseReservation - releases the stock held by a reservation
+func ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	initResponse(&w)
+
+	vars := mux.Vars(r)
+
+	product, err := RepoReserveStock(vars["productID"], vars["reservationID"], 0, 0)
+	writeReservationResult(w, r, product, err)
+}
+
+// CommitReservation - converts a reservation into a stock decrement
+func CommitReservation(w http.ResponseWriter, r *http.Request) {
+	initResponse(&w)
+
+	vars := mux.Vars(r)
+
+	product, err := RepoCommitReservation(vars["productID"], vars["reservationID"])
+	writeReservationResult(w, r, product, err)
+}
+
+// writeReservationResult responds with the product or the reservation error
+func writeReservationResult(w http.ResponseWriter, r *http.Request, product Product, err error) {
+	if stockErr, ok := err.(*InsufficientStockError); ok {
+		w.WriteHeader(http.StatusConflict)
+		if err := json.NewEncoder(w).Encode(stockErr); err != nil {
+			log.Println("Error writing reservation response: ", err)
+		}
+		return
+	}
+
+	switch err {
+	case nil:
+	case ErrProductNotFound, ErrReservationNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case ErrInvalidReservation:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errReservationConflict:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, "Internal error updating reservation", http.StatusInternalServerError)
+		return
+	}
+
+	fullyQualifyProductImageURL(r, &product)
+
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		log.Println("Error writing reservation response: ", err)
+	}
+}
+
 // NewProduct  - creates a new Product
 func NewProduct(w http.ResponseWriter, r *http.Request) {
 	initResponse(&w)
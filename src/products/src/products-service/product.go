@@ -21,6 +21,10 @@ type Product struct {
 	GenderAffinity string   `json:"gender_affinity,omitempty" yaml:"gender_affinity,omitempty"`
 	CurrentStock   int      `json:"current_stock" yaml:"current_stock"`
 	Promoted       string   `json:"promoted,omitempty" yaml:"promoted,omitempty"`
+	// AvailableStock is current stock less active reservations; it is computed and not stored
+	AvailableStock     int          `json:"available_stock" yaml:"-" dynamodbav:"-"`
+	Reservations       Reservations `json:"-" yaml:"-" dynamodbav:"reservations,omitempty"`
+	ReservationVersion int          `json:"-" yaml:"-" dynamodbav:"reservation_version,omitempty"`
 }
 
 // Initialized - indicates if instance has been initialized or not
@@ -29,6 +33,21 @@ func (p *Product) Initialized() bool { return p != nil && len(p.ID) > 0 }
 // Products Array
 type Products []Product
 
+// Reservation Struct - stock held for a cart until it is committed, released or expires
+type Reservation struct {
+	Quantity  int   `json:"quantity" yaml:"quantity" dynamodbav:"quantity"`
+	ExpiresAt int64 `json:"expires_at" yaml:"expires_at" dynamodbav:"expires_at"` // Unix time in seconds
+}
+
+// Reservations Map - keyed by reservation ID
+type Reservations map[string]Reservation
+
+// ReservationRequest Struct
+type ReservationRequest struct {
+	Quantity   int `json:"quantity" yaml:"quantity"`
+	TTLSeconds int `json:"ttl_seconds" yaml:"ttl_seconds"`
+}
+
 // Inventory Struct
 type Inventory struct {
 	StockDelta int `json:"stock_delta" yaml:"stock_delta"`
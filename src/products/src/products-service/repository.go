@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
@@ -64,6 +65,7 @@ func RepoFindProduct(id string) Product {
 		}
 
 		setProductURL(&product)
+		setAvailableStock(&product)
 
 		log.Println("RepoFindProduct returning: ", product.Name, product.Category)
 	}
@@ -119,6 +121,7 @@ func RepoFindMultipleProducts(ids []string) Products {
 				log.Println(err.Error())
 			} else {
 				setProductURL(&product)
+				setAvailableStock(&product)
 			}
 
 			products = append(products, product)
@@ -245,6 +248,7 @@ func RepoFindProductByCategory(category string) Products {
 		expression.Name("price"),
 		expression.Name("gender_affinity"),
 		expression.Name("current_stock"),
+		expression.Name("reservations"),
 		expression.Name("promoted"))
 	expr, err := expression.NewBuilder().WithKeyCondition(keycond).WithProjection(proj).Build()
 
@@ -282,6 +286,7 @@ func RepoFindProductByCategory(category string) Products {
 			log.Println(err.Error())
 		} else {
 			setProductURL(&item)
+			setAvailableStock(&item)
 		}
 
 		f = append(f, item)
@@ -339,6 +344,7 @@ func RepoFindFeatured() Products {
 			log.Println(err.Error())
 		} else {
 			setProductURL(&item)
+			setAvailableStock(&item)
 		}
 
 		f = append(f, item)
@@ -426,6 +432,7 @@ func RepoFindALLProducts() Products {
 			log.Println(err.Error())
 		} else {
 			setProductURL(&item)
+			setAvailableStock(&item)
 		}
 
 		f = append(f, item)
@@ -442,9 +449,36 @@ func RepoFindALLProducts() Products {
 func RepoUpdateProduct(existingProduct *Product, updatedProduct *Product) error {
 	updatedProduct.ID = existingProduct.ID // Ensure we're not changing product ID.
 	updatedProduct.URL = ""                // URL is generated so ignore if specified
-	log.Printf("UpdateProduct from %#v to %#v", existingProduct, updatedProduct)
 
-	av, err := dynamodbattribute.MarshalMap(updatedProduct)
+	for attempt := 0; ; attempt++ {
+		// Reservations are only changed through the reservation functions, so
+		// the product is only written if they are still the ones read
+		updatedProduct.Reservations = existingProduct.Reservations
+		updatedProduct.ReservationVersion = existingProduct.ReservationVersion
+		log.Printf("UpdateProduct from %#v to %#v", existingProduct, updatedProduct)
+
+		err := putProduct(updatedProduct)
+		if err == errReservationConflict && attempt+1 < maxReservationAttempts {
+			*existingProduct = RepoFindProduct(existingProduct.ID)
+			if !existingProduct.Initialized() {
+				return ErrProductNotFound
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		setProductURL(updatedProduct)
+		setAvailableStock(updatedProduct)
+		return nil
+	}
+}
+
+// putProduct writes the product provided its reservation_version is still
+// the one it was read with
+func putProduct(product *Product) error {
+	av, err := dynamodbattribute.MarshalMap(product)
 
 	if err != nil {
 		fmt.Println("Got error calling dynamodbattribute MarshalMap:")
@@ -452,19 +486,33 @@ func RepoUpdateProduct(existingProduct *Product, updatedProduct *Product) error
 		return err
 	}
 
+	var cond expression.ConditionBuilder
+	if product.ReservationVersion == 0 {
+		cond = expression.AttributeNotExists(expression.Name("reservation_version"))
+	} else {
+		cond = expression.Name("reservation_version").Equal(expression.Value(product.ReservationVersion))
+	}
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
 	input := &dynamodb.PutItemInput{
-		Item:      av,
-		TableName: aws.String(ddbTableProducts),
+		Item:                      av,
+		TableName:                 aws.String(ddbTableProducts),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
 	}
 
 	_, err = dynamoClient.PutItem(input)
 	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return errReservationConflict
+		}
 		fmt.Println("Got error calling PutItem:")
 		fmt.Println(err.Error())
 	}
-
-	setProductURL(updatedProduct)
-
 	return err
 }
 
@@ -508,6 +556,7 @@ func RepoUpdateInventoryDelta(product *Product, stockDelta int) error {
 		fmt.Println(err.Error())
 	} else {
 		product.CurrentStock = product.CurrentStock + stockDelta
+		setAvailableStock(product)
 	}
 
 	return err
@@ -540,6 +589,7 @@ func RepoNewProduct(product *Product) error {
 	}
 
 	setProductURL(product)
+	setAvailableStock(product)
 
 	return err
 }
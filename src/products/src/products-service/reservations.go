@@ -0,0 +1,203 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// Reservation lifetimes. Clients may ask for a TTL up to the maximum.
+const (
+	defaultReservationTTL = 15 * time.Minute
+	maxReservationTTL     = 24 * time.Hour
+)
+
+// maxReservationAttempts bounds retries of reservation writes that lose a race
+// with another writer
+const maxReservationAttempts = 5
+
+// ErrProductNotFound is returned when a reservation refers to an unknown product
+var ErrProductNotFound = errors.New("Product not found")
+
+// ErrReservationNotFound is returned when committing a reservation that does
+// not exist or has expired
+var ErrReservationNotFound = errors.New("Reservation not found or expired")
+
+// ErrInvalidReservation is returned for negative quantities or TTLs
+var ErrInvalidReservation = errors.New("Reservation quantity and ttl_seconds cannot be negative")
+
+// errReservationConflict is returned when the product changed while a
+// reservation was being written
+var errReservationConflict = errors.New("Product changed during reservation")
+
+// InsufficientStockError is returned when there is not enough available stock
+// to reserve or commit the requested quantity
+type InsufficientStockError struct {
+	ProductID string `json:"product_id"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+func (e *InsufficientStockError) Error() string {
+	return "Insufficient stock for product: " + e.ProductID
+}
+
+// Active returns the reservations that have not expired at now
+func (r Reservations) Active(now time.Time) Reservations {
+	active := Reservations{}
+	for id, reservation := range r {
+		if reservation.ExpiresAt > now.Unix() {
+			active[id] = reservation
+		}
+	}
+	return active
+}
+
+// Total returns the quantity held by the reservations
+func (r Reservations) Total() int {
+	total := 0
+	for _, reservation := range r {
+		total += reservation.Quantity
+	}
+	return total
+}
+
+// setAvailableStock computes the stock that can still be sold, ignoring
+// expired reservations
+func setAvailableStock(p *Product) {
+	p.AvailableStock = p.CurrentStock - p.Reservations.Active(time.Now()).Total()
+	if p.AvailableStock < 0 {
+		p.AvailableStock = 0
+	}
+}
+
+// RepoReserveStock - sets the quantity held by a reservation. A quantity of
+// zero releases the reservation. Expired reservations are dropped with every
+// write.
+func RepoReserveStock(productID string, reservationID string, quantity int, ttl time.Duration) (Product, error) {
+	if quantity < 0 || ttl < 0 {
+		return Product{}, ErrInvalidReservation
+	}
+	if ttl == 0 {
+		ttl = defaultReservationTTL
+	}
+	if ttl > maxReservationTTL {
+		ttl = maxReservationTTL
+	}
+
+	for attempt := 0; ; attempt++ {
+		product := RepoFindProduct(productID)
+		if !product.Initialized() {
+			return Product{}, ErrProductNotFound
+		}
+
+		now := time.Now()
+		reservations := product.Reservations.Active(now)
+		delete(reservations, reservationID)
+
+		if quantity > 0 {
+			available := product.CurrentStock - reservations.Total()
+			if quantity > available {
+				if available < 0 {
+					available = 0
+				}
+				return Product{}, &InsufficientStockError{ProductID: product.ID, Requested: quantity, Available: available}
+			}
+			reservations[reservationID] = Reservation{Quantity: quantity, ExpiresAt: now.Add(ttl).Unix()}
+		}
+
+		err := putReservations(&product, reservations, 0)
+		if err != errReservationConflict || attempt+1 >= maxReservationAttempts {
+			return product, err
+		}
+	}
+}
+
+// RepoCommitReservation - converts a reservation into a stock decrement
+func RepoCommitReservation(productID string, reservationID string) (Product, error) {
+	for attempt := 0; ; attempt++ {
+		product := RepoFindProduct(productID)
+		if !product.Initialized() {
+			return Product{}, ErrProductNotFound
+		}
+
+		reservations := product.Reservations.Active(time.Now())
+		reservation, ok := reservations[reservationID]
+		if !ok {
+			return Product{}, ErrReservationNotFound
+		}
+		delete(reservations, reservationID)
+
+		if reservation.Quantity > product.CurrentStock {
+			return Product{}, &InsufficientStockError{ProductID: product.ID, Requested: reservation.Quantity, Available: product.CurrentStock}
+		}
+
+		err := putReservations(&product, reservations, -reservation.Quantity)
+		if err != errReservationConflict || attempt+1 >= maxReservationAttempts {
+			return product, err
+		}
+	}
+}
+
+// putReservations stores the product's reservations and applies stockDelta to
+// its current stock, provided neither has changed since the product was read.
+// On success product is updated to match what was stored.
+func putReservations(product *Product, reservations Reservations, stockDelta int) error {
+	av, err := dynamodbattribute.Marshal(reservations)
+	if err != nil {
+		return err
+	}
+
+	update := expression.Set(expression.Name("reservations"), expression.Value(av)).
+		Set(expression.Name("reservation_version"), expression.Value(product.ReservationVersion+1)).
+		Set(expression.Name("current_stock"), expression.Value(product.CurrentStock+stockDelta))
+
+	var versionCond expression.ConditionBuilder
+	if product.ReservationVersion == 0 {
+		versionCond = expression.AttributeNotExists(expression.Name("reservation_version"))
+	} else {
+		versionCond = expression.Name("reservation_version").Equal(expression.Value(product.ReservationVersion))
+	}
+	cond := versionCond.And(expression.Name("current_stock").Equal(expression.Value(product.CurrentStock)))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = dynamoClient.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String(ddbTableProducts),
+		Key: map[string]*dynamodb.AttributeValue{
+			"id": {
+				S: aws.String(product.ID),
+			},
+		},
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return errReservationConflict
+		}
+		log.Println("Got error calling UpdateItem for reservations:")
+		log.Println(err.Error())
+		return err
+	}
+
+	product.Reservations = reservations
+	product.ReservationVersion++
+	product.CurrentStock += stockDelta
+	setAvailableStock(product)
+	return nil
+}
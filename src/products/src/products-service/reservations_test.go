@@ -0,0 +1,268 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestReservationsActive(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	live := Reservation{Quantity: 2, ExpiresAt: now.Add(time.Minute).Unix()}
+	expired := Reservation{Quantity: 3, ExpiresAt: now.Add(-time.Minute).Unix()}
+	expiring := Reservation{Quantity: 4, ExpiresAt: now.Unix()}
+
+	tests := []struct {
+		name         string
+		reservations Reservations
+		want         Reservations
+		wantTotal    int
+	}{
+		{name: "none", reservations: nil, want: Reservations{}, wantTotal: 0},
+		{name: "live", reservations: Reservations{"a": live}, want: Reservations{"a": live}, wantTotal: 2},
+		{name: "expired", reservations: Reservations{"a": expired}, want: Reservations{}, wantTotal: 0},
+		{name: "expiring now", reservations: Reservations{"a": expiring}, want: Reservations{}, wantTotal: 0},
+		{name: "mixed", reservations: Reservations{"a": live, "b": expired, "c": live}, want: Reservations{"a": live, "c": live}, wantTotal: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active := tt.reservations.Active(now)
+			if !reflect.DeepEqual(active, tt.want) {
+				t.Errorf("Active() = %v, want %v", active, tt.want)
+			}
+			if total := active.Total(); total != tt.wantTotal {
+				t.Errorf("Total() = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSetAvailableStock(t *testing.T) {
+	live := time.Now().Add(time.Hour).Unix()
+	expired := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name         string
+		currentStock int
+		reservations Reservations
+		want         int
+	}{
+		{name: "no reservations", currentStock: 10, want: 10},
+		{name: "reserved", currentStock: 10, reservations: Reservations{"a": {Quantity: 3, ExpiresAt: live}, "b": {Quantity: 2, ExpiresAt: live}}, want: 5},
+		{name: "expired reservations ignored", currentStock: 10, reservations: Reservations{"a": {Quantity: 3, ExpiresAt: expired}}, want: 10},
+		{name: "never below zero", currentStock: 2, reservations: Reservations{"a": {Quantity: 3, ExpiresAt: live}}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := Product{CurrentStock: tt.currentStock, Reservations: tt.reservations}
+			setAvailableStock(&product)
+			if product.AvailableStock != tt.want {
+				t.Errorf("AvailableStock = %d, want %d", product.AvailableStock, tt.want)
+			}
+		})
+	}
+}
+
+// fakeProductsTable serves the DynamoDB GetItem and UpdateItem calls the
+// reservation functions make against a products table keyed by "id". It
+// handles the SET updates and the equality and attribute_not_exists
+// conditions they build.
+type fakeProductsTable struct {
+	items map[string]map[string]interface{}
+}
+
+func (f *fakeProductsTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Key                       map[string]map[string]interface{}
+		UpdateExpression          string
+		ConditionExpression       string
+		ExpressionAttributeNames  map[string]string
+		ExpressionAttributeValues map[string]interface{}
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeDynamoError(w, "SerializationException", err.Error())
+		return
+	}
+	id, ok := input.Key["id"]["S"].(string)
+	if !ok || len(input.Key) != 1 {
+		writeDynamoError(w, "ValidationException", "The provided key element does not match the schema")
+		return
+	}
+	item := f.items[id]
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.GetItem":
+		writeDynamoResponse(w, map[string]interface{}{"Item": item})
+	case "DynamoDB_20120810.UpdateItem":
+		for _, cond := range strings.Split(input.ConditionExpression, " AND ") {
+			cond = strings.TrimSuffix(strings.TrimPrefix(cond, "("), ")")
+			if strings.HasPrefix(cond, "attribute_not_exists") {
+				name := input.ExpressionAttributeNames[strings.Trim(strings.TrimPrefix(cond, "attribute_not_exists"), " ()")]
+				if _, exists := item[name]; exists {
+					writeDynamoError(w, "ConditionalCheckFailedException", "The conditional request failed")
+					return
+				}
+				continue
+			}
+			parts := strings.Split(cond, " = ")
+			if !reflect.DeepEqual(item[input.ExpressionAttributeNames[parts[0]]], input.ExpressionAttributeValues[parts[1]]) {
+				writeDynamoError(w, "ConditionalCheckFailedException", "The conditional request failed")
+				return
+			}
+		}
+		for _, set := range strings.Split(strings.TrimPrefix(strings.TrimSpace(input.UpdateExpression), "SET "), ", ") {
+			parts := strings.Split(set, " = ")
+			item[input.ExpressionAttributeNames[parts[0]]] = input.ExpressionAttributeValues[parts[1]]
+		}
+		writeDynamoResponse(w, map[string]interface{}{})
+	default:
+		writeDynamoError(w, "UnknownOperationException", r.Header.Get("X-Amz-Target"))
+	}
+}
+
+func writeDynamoResponse(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeDynamoError(w http.ResponseWriter, code string, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#" + code, "message": message})
+}
+
+// useFakeProductsTable points the repository at a fake table holding a
+// product "shirt" with stock and returns the table with a function that puts
+// the real client back
+func useFakeProductsTable(t *testing.T, stock int) (*fakeProductsTable, func()) {
+	table := &fakeProductsTable{items: map[string]map[string]interface{}{
+		"shirt": {
+			"id":            map[string]interface{}{"S": "shirt"},
+			"category":      map[string]interface{}{"S": "apparel"},
+			"name":          map[string]interface{}{"S": "Shirt"},
+			"current_stock": map[string]interface{}{"N": strconv.Itoa(stock)},
+		},
+	}}
+	server := httptest.NewServer(table)
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("does", "not", "matter"),
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		MaxRetries:  aws.Int(0),
+	})
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	savedClient, savedTable := dynamoClient, ddbTableProducts
+	dynamoClient, ddbTableProducts = dynamodb.New(sess), "products"
+	return table, func() {
+		dynamoClient, ddbTableProducts = savedClient, savedTable
+		server.Close()
+	}
+}
+
+func TestReservationWrites(t *testing.T) {
+	type step struct {
+		name          string
+		reservationID string
+		quantity      int
+		commit        bool
+		wantErr       error
+		wantShortage  bool
+		wantCurrent   int
+		wantAvailable int
+	}
+
+	tests := []struct {
+		name  string
+		stock int
+		steps []step
+	}{
+		{
+			name:  "reserve and commit",
+			stock: 10,
+			steps: []step{
+				{name: "reserve", reservationID: "cart-1", quantity: 3, wantCurrent: 10, wantAvailable: 7},
+				{name: "change reservation", reservationID: "cart-1", quantity: 4, wantCurrent: 10, wantAvailable: 6},
+				{name: "commit", reservationID: "cart-1", commit: true, wantCurrent: 6, wantAvailable: 6},
+				{name: "commit again", reservationID: "cart-1", commit: true, wantErr: ErrReservationNotFound},
+			},
+		},
+		{
+			name:  "reservations share stock",
+			stock: 5,
+			steps: []step{
+				{name: "first cart", reservationID: "cart-1", quantity: 3, wantCurrent: 5, wantAvailable: 2},
+				{name: "second cart too many", reservationID: "cart-2", quantity: 3, wantShortage: true},
+				{name: "release first cart", reservationID: "cart-1", quantity: 0, wantCurrent: 5, wantAvailable: 5},
+				{name: "second cart", reservationID: "cart-2", quantity: 3, wantCurrent: 5, wantAvailable: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, restore := useFakeProductsTable(t, tt.stock)
+			defer restore()
+
+			for _, s := range tt.steps {
+				var product Product
+				var err error
+				if s.commit {
+					product, err = RepoCommitReservation("shirt", s.reservationID)
+				} else {
+					product, err = RepoReserveStock("shirt", s.reservationID, s.quantity, 0)
+				}
+
+				if s.wantShortage {
+					if _, ok := err.(*InsufficientStockError); !ok {
+						t.Fatalf("%s: error = %v, want insufficient stock", s.name, err)
+					}
+					continue
+				}
+				if err != s.wantErr {
+					t.Fatalf("%s: error = %v, want %v", s.name, err, s.wantErr)
+				}
+				if err != nil {
+					continue
+				}
+
+				stored := RepoFindProduct("shirt")
+				for _, p := range []Product{product, stored} {
+					if p.CurrentStock != s.wantCurrent || p.AvailableStock != s.wantAvailable {
+						t.Errorf("%s: current %d available %d, want %d and %d", s.name, p.CurrentStock, p.AvailableStock, s.wantCurrent, s.wantAvailable)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReservationWriteConflict(t *testing.T) {
+	table, restore := useFakeProductsTable(t, 10)
+	defer restore()
+
+	product := RepoFindProduct("shirt")
+	// Another writer changes the reservations after product was read
+	table.items["shirt"]["reservation_version"] = map[string]interface{}{"N": "1"}
+
+	if err := putReservations(&product, Reservations{}, 0); err != errReservationConflict {
+		t.Errorf("putReservations() error = %v, want %v", err, errReservationConflict)
+	}
+}
@@ -71,6 +71,24 @@ var routes = Routes{
 		"/products/id/{productID}/inventory",
 		UpdateInventory,
 	},
+	Route{
+		"InventoryReserve",
+		"PUT",
+		"/products/id/{productID}/reservations/{reservationID}",
+		ReserveInventory,
+	},
+	Route{
+		"InventoryRelease",
+		"DELETE",
+		"/products/id/{productID}/reservations/{reservationID}",
+		ReleaseReservation,
+	},
+	Route{
+		"InventoryCommit",
+		"POST",
+		"/products/id/{productID}/reservations/{reservationID}/commit",
+		CommitReservation,
+	},
 	Route{
 		"CategoryIndex",
 		"GET",
@@ -57,6 +57,14 @@ def test_put_products_id():
 #     integhelpers.put_request_assert(products_api_url, endpoint, request_bodies_path, schemas_path, params)
 
 
+def test_put_delete_products_id_reservations():
+
+    endpoint = "/products/id/:product_id/reservations/:reservation_id"
+    params = {":product_id": test_product_id, ":reservation_id": "integ-test"}
+    integhelpers.put_request_assert(products_api_url, endpoint, request_bodies_path, schemas_path, params)
+    integhelpers.delete_request_assert(products_api_url, endpoint, schemas_path, params)
+
+
 # Test disabled until known issue with this endpoint is resolved
 # def test_delete_products_id():
 #
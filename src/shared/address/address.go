@@ -0,0 +1,252 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package address defines the postal address used by every service and
+// normalizes and validates addresses by country.
+package address
+
+import (
+	"regexp"
+	"strings"
+
+	"shared/validation"
+)
+
+// DefaultCountry is assumed for addresses without a country
+const DefaultCountry = "US"
+
+// Address Struct
+type Address struct {
+	FirstName string `json:"first_name" yaml:"first_name"`
+	LastName  string `json:"last_name" yaml:"last_name"`
+	Address1  string `json:"address1" yaml:"address1"`
+	Address2  string `json:"address2" yaml:"address2"`
+	Country   string `json:"country" yaml:"country"`
+	City      string `json:"city" yaml:"city"`
+	State     string `json:"state" yaml:"state"`
+	ZipCode   string `json:"zipcode" yaml:"zipcode"`
+	Default   bool   `json:"default" yaml:"default"`
+}
+
+// countryCodes maps ISO 3166-1 alpha-2 and alpha-3 codes and country names,
+// upper case, to alpha-2 codes
+var countryCodes = indexCountries(isoCountries)
+
+// regionsByCountry lists the states, provinces or territories of countries
+// whose addresses must have one, by abbreviation
+var regionsByCountry = map[string]map[string]string{
+	"US": {
+		"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas", "CA": "California",
+		"CO": "Colorado", "CT": "Connecticut", "DE": "Delaware", "DC": "District of Columbia",
+		"FL": "Florida", "GA": "Georgia", "HI": "Hawaii", "ID": "Idaho", "IL": "Illinois",
+		"IN": "Indiana", "IA": "Iowa", "KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana",
+		"ME": "Maine", "MD": "Maryland", "MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota",
+		"MS": "Mississippi", "MO": "Missouri", "MT": "Montana", "NE": "Nebraska", "NV": "Nevada",
+		"NH": "New Hampshire", "NJ": "New Jersey", "NM": "New Mexico", "NY": "New York",
+		"NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio", "OK": "Oklahoma", "OR": "Oregon",
+		"PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina", "SD": "South Dakota",
+		"TN": "Tennessee", "TX": "Texas", "UT": "Utah", "VT": "Vermont", "VA": "Virginia",
+		"WA": "Washington", "WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+		"AS": "American Samoa", "GU": "Guam", "MP": "Northern Mariana Islands", "PR": "Puerto Rico",
+		"VI": "U.S. Virgin Islands", "AA": "Armed Forces Americas", "AE": "Armed Forces Europe",
+		"AP": "Armed Forces Pacific",
+	},
+	"CA": {
+		"AB": "Alberta", "BC": "British Columbia", "MB": "Manitoba", "NB": "New Brunswick",
+		"NL": "Newfoundland and Labrador", "NS": "Nova Scotia", "NT": "Northwest Territories",
+		"NU": "Nunavut", "ON": "Ontario", "PE": "Prince Edward Island", "QC": "Quebec",
+		"SK": "Saskatchewan", "YT": "Yukon",
+	},
+	"AU": {
+		"ACT": "Australian Capital Territory", "NSW": "New South Wales", "NT": "Northern Territory",
+		"QLD": "Queensland", "SA": "South Australia", "TAS": "Tasmania", "VIC": "Victoria",
+		"WA": "Western Australia",
+	},
+}
+
+// postalCodeFormats are the formats of normalized postal codes in countries
+// where they are checked, with an example for error messages
+var postalCodeFormats = map[string]struct {
+	pattern *regexp.Regexp
+	example string
+}{
+	"US": {regexp.MustCompile(`^\d{5}(-\d{4})?$`), "12345 or 12345-6789"},
+	"CA": {regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] \d[ABCEGHJ-NPRSTV-Z]\d$`), "K1A 0B1"},
+	"GB": {regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}$`), "SW1A 1AA"},
+	"AU": {regexp.MustCompile(`^\d{4}$`), "2000"},
+	"BR": {regexp.MustCompile(`^\d{5}-\d{3}$`), "01310-100"},
+	"DE": {regexp.MustCompile(`^\d{5}$`), "10115"},
+	"ES": {regexp.MustCompile(`^\d{5}$`), "28001"},
+	"FR": {regexp.MustCompile(`^\d{5}$`), "75001"},
+	"IN": {regexp.MustCompile(`^\d{6}$`), "110001"},
+	"IT": {regexp.MustCompile(`^\d{5}$`), "00118"},
+	"JP": {regexp.MustCompile(`^\d{3}-\d{4}$`), "100-0001"},
+	"MX": {regexp.MustCompile(`^\d{5}$`), "06600"},
+	"NL": {regexp.MustCompile(`^\d{4} [A-Z]{2}$`), "1012 JS"},
+}
+
+// countriesWithoutPostalCodes don't use postal codes, so addresses there
+// don't need one
+var countriesWithoutPostalCodes = map[string]bool{
+	"AE": true, "AG": true, "AO": true, "AW": true, "BF": true, "BI": true, "BJ": true, "BO": true,
+	"BS": true, "BW": true, "BZ": true, "CD": true, "CF": true, "CG": true, "CI": true, "CK": true,
+	"CM": true, "DJ": true, "DM": true, "ER": true, "FJ": true, "GD": true, "GH": true, "GM": true,
+	"GQ": true, "GY": true, "HK": true, "KI": true, "KM": true, "KN": true, "KP": true, "LY": true,
+	"ML": true, "MO": true, "MR": true, "MW": true, "NR": true, "NU": true, "QA": true, "RW": true,
+	"SB": true, "SC": true, "SL": true, "SR": true, "SS": true, "ST": true, "SY": true, "TD": true,
+	"TF": true, "TG": true, "TK": true, "TL": true, "TO": true, "TV": true, "UG": true, "VU": true,
+	"YE": true, "ZW": true,
+}
+
+// postalCodeHyphens are the countries whose postal codes are digits with a
+// hyphen, by the number of digits and where the hyphen goes. US ZIP codes
+// only have one when they are ZIP+4.
+var postalCodeHyphens = map[string]struct{ length, at int }{
+	"US": {9, 5},
+	"BR": {8, 5},
+	"JP": {7, 3},
+}
+
+// postalCodeSpaces are the countries whose postal codes end with a space and
+// the given number of characters
+var postalCodeSpaces = map[string]int{
+	"CA": 3,
+	"GB": 3,
+	"NL": 2,
+}
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// Normalize returns the address with surrounding space trimmed, the country
+// as its ISO 3166-1 alpha-2 code (DefaultCountry when empty),
+// the state as its abbreviation where the country's states are known, and the
+// zip code in the country's format. Values that aren't recognized are left
+// for Validate to report.
+func Normalize(a Address) Address {
+	a.FirstName = strings.TrimSpace(a.FirstName)
+	a.LastName = strings.TrimSpace(a.LastName)
+	a.Address1 = strings.TrimSpace(a.Address1)
+	a.Address2 = strings.TrimSpace(a.Address2)
+	a.City = strings.TrimSpace(a.City)
+
+	a.Country = strings.TrimSpace(a.Country)
+	if len(a.Country) == 0 {
+		a.Country = DefaultCountry
+	} else if code, ok := countryCodes[countryKey(a.Country)]; ok {
+		a.Country = code
+	}
+
+	a.State = normalizeRegion(a.Country, a.State)
+	a.ZipCode = normalizePostalCode(a.Country, a.ZipCode)
+	return a
+}
+
+// Validate returns a problem for each field of a normalized address that is
+// missing or not valid in its country. Fields are named by their JSON keys.
+func Validate(a Address) []validation.FieldError {
+	problems := []validation.FieldError{}
+
+	if len(a.Address1) == 0 {
+		problems = append(problems, validation.FieldError{Field: "address1", Message: "is required"})
+	}
+	if len(a.City) == 0 {
+		problems = append(problems, validation.FieldError{Field: "city", Message: "is required"})
+	}
+
+	if countryCodes[a.Country] != a.Country {
+		problems = append(problems, validation.FieldError{Field: "country", Message: "must be an ISO 3166 country code or name"})
+		return problems
+	}
+
+	if regions, ok := regionsByCountry[a.Country]; ok {
+		if len(a.State) == 0 {
+			problems = append(problems, validation.FieldError{Field: "state", Message: "is required"})
+		} else if _, ok := regions[a.State]; !ok {
+			problems = append(problems, validation.FieldError{Field: "state", Message: "must be a state or territory of " + a.Country})
+		}
+	}
+
+	if len(a.ZipCode) == 0 {
+		if !countriesWithoutPostalCodes[a.Country] {
+			problems = append(problems, validation.FieldError{Field: "zipcode", Message: "is required"})
+		}
+	} else if format, ok := postalCodeFormats[a.Country]; ok && !format.pattern.MatchString(a.ZipCode) {
+		problems = append(problems, validation.FieldError{Field: "zipcode", Message: "must be a postal code like " + format.example})
+	}
+
+	return problems
+}
+
+// Default returns the first address marked as the default, or the first
+// address when none is. It returns false when there are no addresses.
+func Default(addresses []Address) (Address, bool) {
+	for _, a := range addresses {
+		if a.Default {
+			return a, true
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0], true
+	}
+	return Address{}, false
+}
+
+// normalizeRegion returns the abbreviation of a state given by abbreviation
+// or name, or the trimmed state when the country's states aren't known
+func normalizeRegion(country string, state string) string {
+	state = strings.TrimSpace(state)
+	regions, ok := regionsByCountry[country]
+	if !ok {
+		return state
+	}
+
+	upper := strings.ToUpper(strings.Replace(state, ".", "", -1))
+	if _, ok := regions[upper]; ok {
+		return upper
+	}
+	for abbreviation, name := range regions {
+		if strings.EqualFold(name, state) {
+			return abbreviation
+		}
+	}
+	return state
+}
+
+// normalizePostalCode puts a postal code in its country's usual format
+func normalizePostalCode(country string, zip string) string {
+	zip = strings.ToUpper(strings.Join(strings.Fields(zip), " "))
+	compact := strings.Replace(zip, " ", "", -1)
+
+	if hyphen, ok := postalCodeHyphens[country]; ok {
+		// Digits written without the hyphen or with another separator
+		digits := nonDigits.ReplaceAllString(compact, "")
+		if len(digits) == hyphen.length && len(compact) <= len(digits)+1 {
+			return digits[:hyphen.at] + "-" + digits[hyphen.at:]
+		}
+		return compact
+	}
+	if suffix, ok := postalCodeSpaces[country]; ok {
+		if len(compact) > suffix {
+			return compact[:len(compact)-suffix] + " " + compact[len(compact)-suffix:]
+		}
+		return compact
+	}
+	return zip
+}
+
+// countryKey returns the key a country is looked up by in countryCodes
+func countryKey(country string) string {
+	return strings.ToUpper(strings.Replace(strings.TrimSpace(country), ".", "", -1))
+}
+
+func indexCountries(countries []isoCountry) map[string]string {
+	index := make(map[string]string, len(countries)*3)
+	for _, c := range countries {
+		index[c.Alpha2] = c.Alpha2
+		index[c.Alpha3] = c.Alpha2
+		for _, name := range c.Names {
+			index[countryKey(name)] = c.Alpha2
+		}
+	}
+	return index
+}
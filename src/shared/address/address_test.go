@@ -0,0 +1,153 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package address
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Address
+		want Address
+	}{
+		{
+			name: "empty country defaults",
+			in:   Address{Address1: " 1 Main St ", City: " Seattle", State: "wa", ZipCode: "98101"},
+			want: Address{Address1: "1 Main St", City: "Seattle", Country: "US", State: "WA", ZipCode: "98101"},
+		},
+		{
+			name: "country and state names",
+			in:   Address{Country: "United States", State: "Washington", ZipCode: "981011234"},
+			want: Address{Country: "US", State: "WA", ZipCode: "98101-1234"},
+		},
+		{
+			name: "alpha-3 country",
+			in:   Address{Country: "usa", State: "N.Y.", ZipCode: "10001 2222"},
+			want: Address{Country: "US", State: "NY", ZipCode: "10001-2222"},
+		},
+		{
+			name: "canadian postal code spacing",
+			in:   Address{Country: "CAN", State: "ontario", ZipCode: "k1a0b1"},
+			want: Address{Country: "CA", State: "ON", ZipCode: "K1A 0B1"},
+		},
+		{
+			name: "uk postcode",
+			in:   Address{Country: "GB", ZipCode: "sw1a  1aa"},
+			want: Address{Country: "GB", ZipCode: "SW1A 1AA"},
+		},
+		{
+			name: "japanese postal code hyphen",
+			in:   Address{Country: "Japan", ZipCode: "1000001"},
+			want: Address{Country: "JP", ZipCode: "100-0001"},
+		},
+		{
+			name: "unknown values are kept",
+			in:   Address{Country: "Atlantis", State: "Nowhere", ZipCode: "abc"},
+			want: Address{Country: "Atlantis", State: "Nowhere", ZipCode: "ABC"},
+		},
+		{
+			name: "unknown state in a country with states",
+			in:   Address{Country: "US", State: "Ontario"},
+			want: Address{Country: "US", State: "Ontario"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     Address
+		fields []string
+	}{
+		{
+			name:   "valid us address",
+			in:     Address{Address1: "1 Main St", City: "Seattle", Country: "US", State: "WA", ZipCode: "98101"},
+			fields: []string{},
+		},
+		{
+			name:   "missing street and city",
+			in:     Address{Country: "US", State: "WA", ZipCode: "98101"},
+			fields: []string{"address1", "city"},
+		},
+		{
+			name:   "unknown country stops further checks",
+			in:     Address{Address1: "1 Main St", City: "Seattle", Country: "Atlantis"},
+			fields: []string{"country"},
+		},
+		{
+			name:   "missing state and zip code",
+			in:     Address{Address1: "1 Main St", City: "Seattle", Country: "US"},
+			fields: []string{"state", "zipcode"},
+		},
+		{
+			name:   "state of another country",
+			in:     Address{Address1: "1 Main St", City: "Toronto", Country: "CA", State: "WA", ZipCode: "M5V 2T6"},
+			fields: []string{"state"},
+		},
+		{
+			name:   "badly formatted zip code",
+			in:     Address{Address1: "1 Main St", City: "Seattle", Country: "US", State: "WA", ZipCode: "9810"},
+			fields: []string{"zipcode"},
+		},
+		{
+			name:   "country without postal codes",
+			in:     Address{Address1: "1 Sheikh Zayed Rd", City: "Dubai", Country: "AE"},
+			fields: []string{},
+		},
+		{
+			name:   "unchecked zip code format",
+			in:     Address{Address1: "1 Rue", City: "Brussels", Country: "BE", ZipCode: "anything"},
+			fields: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []string{}
+			for _, problem := range Validate(tt.in) {
+				fields = append(fields, problem.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("Validate() fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	home := Address{Address1: "home"}
+	work := Address{Address1: "work", Default: true}
+	other := Address{Address1: "other", Default: true}
+
+	tests := []struct {
+		name      string
+		addresses []Address
+		want      Address
+		ok        bool
+	}{
+		{name: "none", addresses: nil, want: Address{}, ok: false},
+		{name: "first when none marked", addresses: []Address{home, {Address1: "second"}}, want: home, ok: true},
+		{name: "first marked", addresses: []Address{home, work, other}, want: work, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Default(tt.addresses)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Default() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package catalog looks up products in the products service.
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"shared/services"
+)
+
+// Maximum number of product IDs the products service accepts per request
+const maxProductsPerRequest = 100
+
+// Product Struct - the fields of a products service Product used by other services
+type Product struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Category       string  `json:"category"`
+	Price          float32 `json:"price"`
+	CurrentStock   int     `json:"current_stock"`
+	AvailableStock int     `json:"available_stock"`
+}
+
+// FindProducts returns the products that exist for ids keyed by product ID,
+// looking them up in batches the products service accepts
+func FindProducts(client *http.Client, endpoint *services.Endpoint, ids []string) (map[string]Product, error) {
+	products := make(map[string]Product, len(ids))
+
+	ids = uniqueStrings(ids)
+	for start := 0; start < len(ids); start += maxProductsPerRequest {
+		end := start + maxProductsPerRequest
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch, err := findBatch(client, endpoint, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range batch {
+			if len(p.ID) > 0 {
+				products[p.ID] = p
+			}
+		}
+	}
+
+	return products, nil
+}
+
+// findBatch calls GET /products/id/{ids}. The products service returns a
+// single object (or 404) for one ID and an array for several.
+func findBatch(client *http.Client, endpoint *services.Endpoint, ids []string) ([]Product, error) {
+	escaped := make([]string, len(ids))
+	for i, id := range ids {
+		escaped[i] = url.PathEscape(id)
+	}
+
+	body, status, err := endpoint.Do(client, "GET", "/products/id/"+strings.Join(escaped, ",")+"?fullyQualifyImageUrls=0", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound && len(ids) == 1 {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("products service returned status %d", status)
+	}
+
+	var products []Product
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &products)
+	} else {
+		var product Product
+		err = json.Unmarshal(trimmed, &product)
+		products = append(products, product)
+	}
+
+	return products, err
+}
+
+// uniqueStrings returns values without duplicates, preserving order
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,5 @@
+module shared
+
+go 1.11
+
+require github.com/aws/aws-sdk-go v1.44.97
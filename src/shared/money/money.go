@@ -0,0 +1,12 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package money rounds amounts the way every service reports them.
+package money
+
+import "math"
+
+// Round rounds an amount to whole cents
+func Round(amount float64) float32 {
+	return float32(math.Round(amount*100) / 100)
+}
@@ -0,0 +1,241 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package promotions defines the promotion codes offered by the store and
+// works out the discounts they give, so carts and orders price them the same
+// way, and counts the uses of each code in a UsageStore the services share.
+package promotions
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"shared/money"
+)
+
+// Promotion types
+const (
+	PercentOff   = "PERCENT_OFF"
+	FixedOff     = "FIXED_OFF"
+	FreeShipping = "FREE_SHIPPING"
+	BuyXGetY     = "BUY_X_GET_Y"
+)
+
+// Errors returned when a promotion can't be used
+var (
+	ErrNotFound  = errors.New("Promotion code not found")
+	ErrNotActive = errors.New("Promotion code is not valid at this time")
+	ErrUsedUp    = errors.New("Promotion code has reached its usage limit")
+)
+
+// Promotion Struct - a coupon code and the discount it gives. Promotions
+// without product IDs or categories apply to every item.
+type Promotion struct {
+	Code        string     `json:"code" yaml:"code"`
+	Description string     `json:"description" yaml:"description"`
+	Type        string     `json:"type" yaml:"type"`
+	Value       float32    `json:"value" yaml:"value"` // percent for PERCENT_OFF, amount for FIXED_OFF
+	BuyQuantity int        `json:"buy_quantity,omitempty" yaml:"buy_quantity,omitempty"`
+	GetQuantity int        `json:"get_quantity,omitempty" yaml:"get_quantity,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty" yaml:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty" yaml:"ends_at,omitempty"`
+	UsageLimit  int        `json:"usage_limit" yaml:"usage_limit"` // 0 is unlimited
+	TimesUsed   int        `json:"times_used" yaml:"times_used"`
+	ProductIDs  []string   `json:"product_ids,omitempty" yaml:"product_ids,omitempty"`
+	Categories  []string   `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+// Applied Struct - a promotion applied to priced items
+type Applied struct {
+	Code         string  `json:"code" yaml:"code"`
+	Description  string  `json:"description" yaml:"description"`
+	Discount     float32 `json:"discount" yaml:"discount"`
+	FreeShipping bool    `json:"free_shipping" yaml:"free_shipping"`
+}
+
+// Line Struct - a priced line of a cart or order. Apply adds each
+// promotion's discount to Discount.
+type Line struct {
+	ProductID string
+	Category  string
+	Price     float32
+	Quantity  int
+	Discount  float32
+}
+
+// NormalizeCode returns code in the form promotions are stored under
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Active reports whether the promotion can be used at now
+func (p Promotion) Active(now time.Time) error {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return ErrNotActive
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return ErrNotActive
+	}
+	if p.UsageLimit > 0 && p.TimesUsed >= p.UsageLimit {
+		return ErrUsedUp
+	}
+	return nil
+}
+
+// AppliesTo reports whether the promotion targets a product
+func (p Promotion) AppliesTo(productID string, category string) bool {
+	if len(p.ProductIDs) == 0 && len(p.Categories) == 0 {
+		return true
+	}
+	for _, id := range p.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	for _, c := range p.Categories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Configured returns the promotions in the JSON file at path, or the default
+// promotions when path is empty or the file can't be loaded
+func Configured(path string) []Promotion {
+	if len(path) == 0 {
+		return Defaults()
+	}
+
+	promotions, err := Load(path)
+	if err != nil {
+		log.Println("Unable to load promotions; using defaults: ", path, err)
+		return Defaults()
+	}
+	log.Println("Loaded promotions from: ", path)
+	return promotions
+}
+
+// Load reads a JSON array of promotions
+func Load(path string) ([]Promotion, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var promotions []Promotion
+	if err := json.Unmarshal(data, &promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// Defaults are available when no promotions file is configured
+func Defaults() []Promotion {
+	return []Promotion{
+		{Code: "WELCOME10", Description: "10% off your order", Type: PercentOff, Value: 10},
+		{Code: "SAVE5", Description: "5.00 off your order", Type: FixedOff, Value: 5},
+		{Code: "FREESHIP", Description: "Free shipping", Type: FreeShipping},
+		{Code: "BOGO", Description: "Buy one, get one free", Type: BuyXGetY, BuyQuantity: 1, GetQuantity: 1},
+	}
+}
+
+// Apply discounts lines with each promotion in turn. Each promotion discounts
+// what is left of a line after earlier promotions, so a line is never
+// discounted below zero. It returns what each promotion gave and whether any
+// of them gives free shipping.
+func Apply(lines []Line, promotions []Promotion) ([]Applied, bool) {
+	applied := make([]Applied, 0, len(promotions))
+	freeShipping := false
+
+	for _, promotion := range promotions {
+		result := Applied{Code: promotion.Code, Description: promotion.Description}
+		eligible := eligibleLines(lines, promotion)
+
+		switch promotion.Type {
+		case PercentOff:
+			for _, i := range eligible {
+				result.Discount += addDiscount(&lines[i], float64(remaining(lines[i]))*float64(promotion.Value)/100)
+			}
+		case FixedOff:
+			result.Discount = distributeDiscount(lines, eligible, float64(promotion.Value))
+		case BuyXGetY:
+			if promotion.BuyQuantity > 0 && promotion.GetQuantity > 0 {
+				group := promotion.BuyQuantity + promotion.GetQuantity
+				for _, i := range eligible {
+					free := lines[i].Quantity / group * promotion.GetQuantity
+					result.Discount += addDiscount(&lines[i], float64(lines[i].Price)*float64(free))
+				}
+			}
+		case FreeShipping:
+			result.FreeShipping = len(lines) > 0
+			freeShipping = freeShipping || result.FreeShipping
+		}
+
+		result.Discount = money.Round(float64(result.Discount))
+		applied = append(applied, result)
+	}
+	return applied, freeShipping
+}
+
+// eligibleLines returns the indexes of the lines the promotion applies to
+func eligibleLines(lines []Line, promotion Promotion) []int {
+	var eligible []int
+	for i, line := range lines {
+		if promotion.AppliesTo(line.ProductID, line.Category) {
+			eligible = append(eligible, i)
+		}
+	}
+	return eligible
+}
+
+// remaining returns the amount of a line not yet discounted
+func remaining(line Line) float32 {
+	return money.Round(float64(line.Price)*float64(line.Quantity) - float64(line.Discount))
+}
+
+// addDiscount adds up to amount to the line's discount, never taking the line
+// below zero, and returns the discount actually added
+func addDiscount(line *Line, amount float64) float32 {
+	left := float64(remaining(*line))
+	if amount > left {
+		amount = left
+	}
+	if amount <= 0 {
+		return 0
+	}
+
+	discount := money.Round(amount)
+	line.Discount = money.Round(float64(line.Discount) + float64(discount))
+	return discount
+}
+
+// distributeDiscount spreads a fixed amount across the eligible lines in
+// proportion to what is left of each, and returns the total discount given
+func distributeDiscount(lines []Line, eligible []int, amount float64) float32 {
+	var left float64
+	for _, i := range eligible {
+		left += float64(remaining(lines[i]))
+	}
+	if left <= 0 || amount <= 0 {
+		return 0
+	}
+	if amount > left {
+		amount = left
+	}
+
+	var given float32
+	for n, i := range eligible {
+		share := amount * float64(remaining(lines[i])) / left
+		if n == len(eligible)-1 {
+			// The last line takes what rounding left over
+			share = amount - float64(given)
+		}
+		given += addDiscount(&lines[i], share)
+	}
+	return given
+}
@@ -0,0 +1,174 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package promotions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApply(t *testing.T) {
+	shirt := Line{ProductID: "shirt", Category: "apparel", Price: 20, Quantity: 1}
+	socks := Line{ProductID: "socks", Category: "apparel", Price: 5, Quantity: 3}
+	apple := Line{ProductID: "apple", Category: "groceries", Price: 1.25, Quantity: 4}
+
+	percent := Promotion{Code: "WELCOME10", Type: PercentOff, Value: 10}
+	fixed := Promotion{Code: "SAVE5", Type: FixedOff, Value: 5}
+	bogo := Promotion{Code: "BOGO", Type: BuyXGetY, BuyQuantity: 1, GetQuantity: 1}
+	ship := Promotion{Code: "FREESHIP", Type: FreeShipping}
+	groceries := Promotion{Code: "FRUIT50", Type: PercentOff, Value: 50, Categories: []string{"Groceries"}}
+	huge := Promotion{Code: "HUGE", Type: FixedOff, Value: 1000}
+
+	tests := []struct {
+		name          string
+		lines         []Line
+		promotions    []Promotion
+		wantDiscounts []float32
+		wantApplied   []float32
+		wantFree      bool
+	}{
+		{
+			name:          "no promotions",
+			lines:         []Line{shirt},
+			wantDiscounts: []float32{0},
+			wantApplied:   []float32{},
+		},
+		{
+			name:          "percent off every line",
+			lines:         []Line{shirt, socks},
+			promotions:    []Promotion{percent},
+			wantDiscounts: []float32{2, 1.5},
+			wantApplied:   []float32{3.5},
+		},
+		{
+			name:          "fixed off spread by what is left of each line",
+			lines:         []Line{shirt, socks},
+			promotions:    []Promotion{fixed},
+			wantDiscounts: []float32{2.86, 2.14},
+			wantApplied:   []float32{5},
+		},
+		{
+			name:          "later promotions discount what is left",
+			lines:         []Line{shirt},
+			promotions:    []Promotion{fixed, percent},
+			wantDiscounts: []float32{6.5},
+			wantApplied:   []float32{5, 1.5},
+		},
+		{
+			name:          "buy one get one",
+			lines:         []Line{socks},
+			promotions:    []Promotion{bogo},
+			wantDiscounts: []float32{5},
+			wantApplied:   []float32{5},
+		},
+		{
+			name:          "category restricted",
+			lines:         []Line{shirt, apple},
+			promotions:    []Promotion{groceries},
+			wantDiscounts: []float32{0, 2.5},
+			wantApplied:   []float32{2.5},
+		},
+		{
+			name:          "never below zero",
+			lines:         []Line{shirt, socks},
+			promotions:    []Promotion{huge, percent},
+			wantDiscounts: []float32{20, 15},
+			wantApplied:   []float32{35, 0},
+		},
+		{
+			name:          "free shipping",
+			lines:         []Line{shirt},
+			promotions:    []Promotion{ship},
+			wantDiscounts: []float32{0},
+			wantApplied:   []float32{0},
+			wantFree:      true,
+		},
+		{
+			name:          "free shipping needs items",
+			lines:         []Line{},
+			promotions:    []Promotion{ship},
+			wantDiscounts: []float32{},
+			wantApplied:   []float32{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := append([]Line{}, tt.lines...)
+			applied, free := Apply(lines, tt.promotions)
+
+			discounts := []float32{}
+			for _, line := range lines {
+				discounts = append(discounts, line.Discount)
+			}
+			if !reflect.DeepEqual(discounts, tt.wantDiscounts) {
+				t.Errorf("line discounts = %v, want %v", discounts, tt.wantDiscounts)
+			}
+
+			given := []float32{}
+			for _, a := range applied {
+				given = append(given, a.Discount)
+			}
+			if !reflect.DeepEqual(given, tt.wantApplied) {
+				t.Errorf("applied discounts = %v, want %v", given, tt.wantApplied)
+			}
+			if free != tt.wantFree {
+				t.Errorf("free shipping = %v, want %v", free, tt.wantFree)
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		promotion Promotion
+		want      error
+	}{
+		{name: "no limits", promotion: Promotion{}, want: nil},
+		{name: "not started", promotion: Promotion{StartsAt: &later}, want: ErrNotActive},
+		{name: "started", promotion: Promotion{StartsAt: &earlier}, want: nil},
+		{name: "ended", promotion: Promotion{EndsAt: &earlier}, want: ErrNotActive},
+		{name: "ends exactly now", promotion: Promotion{EndsAt: &now}, want: ErrNotActive},
+		{name: "used up", promotion: Promotion{UsageLimit: 2, TimesUsed: 2}, want: ErrUsedUp},
+		{name: "uses left", promotion: Promotion{UsageLimit: 2, TimesUsed: 1}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promotion.Active(now); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppliesTo(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion Promotion
+		productID string
+		category  string
+		want      bool
+	}{
+		{name: "everything", promotion: Promotion{}, productID: "1", category: "apparel", want: true},
+		{name: "listed product", promotion: Promotion{ProductIDs: []string{"1"}}, productID: "1", want: true},
+		{name: "other product", promotion: Promotion{ProductIDs: []string{"1"}}, productID: "2", want: false},
+		{name: "category ignores case", promotion: Promotion{Categories: []string{"Apparel"}}, productID: "2", category: "apparel", want: true},
+		{name: "other category", promotion: Promotion{Categories: []string{"apparel"}}, productID: "2", category: "groceries", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promotion.AppliesTo(tt.productID, tt.category); got != tt.want {
+				t.Errorf("AppliesTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
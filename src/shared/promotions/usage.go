@@ -0,0 +1,227 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package promotions
+
+import (
+	"log"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// UsageStore counts the uses of promotion codes. Every service and instance
+// that checks usage limits must share one store for the limits to hold.
+// Implementations must be safe for concurrent use.
+type UsageStore interface {
+	// TimesUsed returns the uses counted for each of codes
+	TimesUsed(codes []string) (map[string]int, error)
+	// Redeem counts a use of code unless limit uses have already been
+	// counted, in which case it returns ErrUsedUp. A limit of 0 is unlimited.
+	Redeem(code string, limit int) error
+	// Unredeem reverses Redeem for a use that did not go ahead
+	Unredeem(code string) error
+}
+
+// CountUses sets the TimesUsed of each promotion from store
+func CountUses(store UsageStore, values []Promotion) error {
+	codes := make([]string, len(values))
+	for i, p := range values {
+		codes[i] = p.Code
+	}
+	counts, err := store.TimesUsed(codes)
+	if err != nil {
+		return err
+	}
+	for i := range values {
+		values[i].TimesUsed = counts[values[i].Code]
+	}
+	return nil
+}
+
+// MemoryUsageStore counts uses in process memory. Counts are lost on restart
+// and aren't shared with other processes.
+type MemoryUsageStore struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewMemoryUsageStore Function
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{counts: map[string]int{}}
+}
+
+// TimesUsed Function
+func (s *MemoryUsageStore) TimesUsed(codes []string) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int, len(codes))
+	for _, code := range codes {
+		counts[code] = s.counts[code]
+	}
+	return counts, nil
+}
+
+// Redeem Function
+func (s *MemoryUsageStore) Redeem(code string, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if limit > 0 && s.counts[code] >= limit {
+		return ErrUsedUp
+	}
+	s.counts[code]++
+	return nil
+}
+
+// Unredeem Function
+func (s *MemoryUsageStore) Unredeem(code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts[code] > 0 {
+		s.counts[code]--
+	}
+	return nil
+}
+
+// DynamoUsageStore counts uses in a DynamoDB table keyed by "code", with the
+// count in "times_used". Uses are counted with conditional updates, so a limit
+// holds however many instances redeem the code.
+type DynamoUsageStore struct {
+	client    dynamodbiface.DynamoDBAPI
+	tableName string
+}
+
+// NewDynamoUsageStore Function
+func NewDynamoUsageStore(client dynamodbiface.DynamoDBAPI, tableName string) *DynamoUsageStore {
+	return &DynamoUsageStore{client: client, tableName: tableName}
+}
+
+// usage is an item of the usage table
+type usage struct {
+	Code      string `dynamodbav:"code"`
+	TimesUsed int    `dynamodbav:"times_used"`
+}
+
+// TimesUsed Function
+func (s *DynamoUsageStore) TimesUsed(codes []string) (map[string]int, error) {
+	counts := make(map[string]int, len(codes))
+	for _, code := range codes {
+		result, err := s.client.GetItem(&dynamodb.GetItemInput{
+			TableName:      aws.String(s.tableName),
+			Key:            usageKey(code),
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			log.Println("Got error reading promotion usage:")
+			log.Println(err.Error())
+			return nil, err
+		}
+
+		var item usage
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &item); err != nil {
+			return nil, err
+		}
+		counts[code] = item.TimesUsed
+	}
+	return counts, nil
+}
+
+// Redeem Function
+func (s *DynamoUsageStore) Redeem(code string, limit int) error {
+	builder := expression.NewBuilder().WithUpdate(expression.Add(expression.Name("times_used"), expression.Value(1)))
+	if limit > 0 {
+		builder = builder.WithCondition(expression.Or(
+			expression.AttributeNotExists(expression.Name("times_used")),
+			expression.Name("times_used").LessThan(expression.Value(limit)),
+		))
+	}
+
+	err := s.update(code, builder)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrUsedUp
+	}
+	return err
+}
+
+// Unredeem Function
+func (s *DynamoUsageStore) Unredeem(code string) error {
+	builder := expression.NewBuilder().
+		WithUpdate(expression.Add(expression.Name("times_used"), expression.Value(-1))).
+		WithCondition(expression.Name("times_used").GreaterThan(expression.Value(0)))
+
+	err := s.update(code, builder)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// Nothing was counted
+		return nil
+	}
+	return err
+}
+
+func (s *DynamoUsageStore) update(code string, builder expression.Builder) error {
+	expr, err := builder.Build()
+	if err != nil {
+		log.Println("Got error building expression:")
+		log.Println(err.Error())
+		return err
+	}
+
+	_, err = s.client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       usageKey(code),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+			log.Println("Got error updating promotion usage:")
+			log.Println(err.Error())
+		}
+	}
+	return err
+}
+
+// CreateUsageTable creates the usage table for local development, doing
+// nothing if it already exists
+func CreateUsageTable(client dynamodbiface.DynamoDBAPI, tableName string) error {
+	log.Println("Creating promotion usage table: ", tableName)
+
+	_, err := client.CreateTable(&dynamodb.CreateTableInput{
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{
+				AttributeName: aws.String("code"),
+				AttributeType: aws.String("S"),
+			},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{
+				AttributeName: aws.String("code"),
+				KeyType:       aws.String("HASH"),
+			},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+		TableName:   aws.String(tableName),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceInUseException {
+		log.Println("Table already exists; continuing")
+		return nil
+	}
+	return err
+}
+
+func usageKey(code string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"code": {
+			S: aws.String(code),
+		},
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package promotions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// fakeUsageTable serves the DynamoDB GetItem and UpdateItem calls
+// DynamoUsageStore makes, checking the limit and greater than zero conditions
+// it builds
+type fakeUsageTable struct {
+	counts map[string]int
+}
+
+func (f *fakeUsageTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Key                       map[string]map[string]string
+		UpdateExpression          string
+		ConditionExpression       string
+		ExpressionAttributeValues map[string]map[string]string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeDynamoError(w, "SerializationException", err.Error())
+		return
+	}
+	code := input.Key["code"]["S"]
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.GetItem":
+		if count, ok := f.counts[code]; ok {
+			writeDynamoResponse(w, map[string]interface{}{"Item": map[string]interface{}{
+				"code":       map[string]string{"S": code},
+				"times_used": map[string]string{"N": strconv.Itoa(count)},
+			}})
+			return
+		}
+		writeDynamoResponse(w, map[string]interface{}{})
+	case "DynamoDB_20120810.UpdateItem":
+		// The update is "ADD #name :value" and the condition, when there is
+		// one, ends with the limit or bound
+		add, bound := value(input.ExpressionAttributeValues, input.UpdateExpression), value(input.ExpressionAttributeValues, input.ConditionExpression)
+		count, exists := f.counts[code]
+		switch {
+		case strings.Contains(input.ConditionExpression, "<") && exists && count >= bound,
+			strings.Contains(input.ConditionExpression, ">") && count <= bound:
+			writeDynamoError(w, "ConditionalCheckFailedException", "The conditional request failed")
+			return
+		}
+		f.counts[code] = count + add
+		writeDynamoResponse(w, map[string]interface{}{})
+	default:
+		writeDynamoError(w, "UnknownOperationException", r.Header.Get("X-Amz-Target"))
+	}
+}
+
+// value returns the number named by the last placeholder in expr
+func value(values map[string]map[string]string, expr string) int {
+	fields := strings.Fields(strings.TrimRight(expr, ")"))
+	if len(fields) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(values[fields[len(fields)-1]]["N"])
+	return n
+}
+
+func writeDynamoResponse(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeDynamoError(w http.ResponseWriter, code string, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#" + code, "message": message})
+}
+
+func newFakeDynamoUsageStore(t *testing.T) (*DynamoUsageStore, func()) {
+	server := httptest.NewServer(&fakeUsageTable{counts: map[string]int{}})
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("does", "not", "matter"),
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		MaxRetries:  aws.Int(0),
+	})
+	if err != nil {
+		t.Fatalf("NewSession() error = %v", err)
+	}
+	return NewDynamoUsageStore(dynamodb.New(sess), "promotion-usage"), server.Close
+}
+
+func TestUsageStores(t *testing.T) {
+	type step struct {
+		name    string
+		redeem  bool
+		limit   int
+		wantErr error
+		want    int
+	}
+
+	steps := []step{
+		{name: "redeem", redeem: true, limit: 2, want: 1},
+		{name: "redeem up to the limit", redeem: true, limit: 2, want: 2},
+		{name: "redeem past the limit", redeem: true, limit: 2, wantErr: ErrUsedUp, want: 2},
+		{name: "unredeem", want: 1},
+		{name: "redeem again", redeem: true, limit: 2, want: 2},
+		{name: "no limit", redeem: true, limit: 0, want: 3},
+		{name: "unredeem", want: 2},
+		{name: "unredeem", want: 1},
+		{name: "unredeem", want: 0},
+		{name: "unredeem with nothing counted", want: 0},
+	}
+
+	dynamo, closeDynamo := newFakeDynamoUsageStore(t)
+	defer closeDynamo()
+	stores := map[string]UsageStore{"memory": NewMemoryUsageStore(), "dynamo": dynamo}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, s := range steps {
+				var err error
+				if s.redeem {
+					err = store.Redeem("WELCOME10", s.limit)
+				} else {
+					err = store.Unredeem("WELCOME10")
+				}
+				if err != s.wantErr {
+					t.Fatalf("%s: error = %v, want %v", s.name, err, s.wantErr)
+				}
+
+				values := []Promotion{{Code: "WELCOME10"}, {Code: "UNUSED"}}
+				if err := CountUses(store, values); err != nil {
+					t.Fatalf("%s: CountUses() error = %v", s.name, err)
+				}
+				if values[0].TimesUsed != s.want || values[1].TimesUsed != 0 {
+					t.Errorf("%s: times used = %d and %d, want %d and 0", s.name, values[0].TimesUsed, values[1].TimesUsed, s.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package services locates other Retail Demo Store services and calls their
+// REST APIs.
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+)
+
+// Cloud Map namespace the services are registered in
+const namespace = "retaildemostore.local"
+
+// Maximum size of a response body read from a service
+const maxResponseBytes = 10485760
+
+// Endpoint locates another Retail Demo Store service. The host and port come
+// from the environment when running locally; otherwise the service is
+// discovered through Cloud Map by its service name. A discovered instance is
+// reused until a request to it fails, and then discovered again.
+type Endpoint struct {
+	name    string
+	host    string
+	port    string
+	session client.ConfigProvider
+	mu      sync.Mutex
+	baseURL string
+}
+
+// NewEndpoint returns the endpoint of the service called name. When host is
+// empty the service is discovered through Cloud Map with session.
+func NewEndpoint(name string, host string, port string, session client.ConfigProvider) *Endpoint {
+	if len(port) == 0 {
+		port = "80"
+	}
+	return &Endpoint{name: name, host: host, port: port, session: session}
+}
+
+// URL returns the base URL of the service
+func (e *Endpoint) URL() (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.baseURL) > 0 {
+		return e.baseURL, nil
+	}
+
+	host := e.host
+	if len(host) == 0 {
+		result, err := servicediscovery.New(e.session).DiscoverInstances(&servicediscovery.DiscoverInstancesInput{
+			NamespaceName: aws.String(namespace),
+			ServiceName:   aws.String(e.name),
+			MaxResults:    aws.Int64(1),
+			HealthStatus:  aws.String("HEALTHY"),
+		})
+		if err != nil {
+			log.Println("Unable to discover service instance: ", e.name, err)
+			return "", err
+		}
+		if len(result.Instances) == 0 {
+			return "", errors.New("No healthy service instances found")
+		}
+		host = aws.StringValue(result.Instances[0].Attributes["AWS_INSTANCE_IPV4"])
+	}
+
+	e.baseURL = "http://" + host + ":" + e.port
+	log.Println("Resolved service endpoint: ", e.baseURL)
+	return e.baseURL, nil
+}
+
+// forget drops a discovered instance that could not be reached, so the next
+// request discovers one again. Configured hosts are kept.
+func (e *Endpoint) forget(baseURL string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.host) == 0 && e.baseURL == baseURL {
+		log.Println("Forgetting unreachable service endpoint: ", baseURL)
+		e.baseURL = ""
+	}
+}
+
+// Do sends a request with an optional JSON payload and headers to the service
+// and returns the response body and status code
+func (e *Endpoint) Do(client *http.Client, method string, path string, payload interface{}, header http.Header) ([]byte, int, error) {
+	baseURL, err := e.URL()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, 0, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, baseURL+path, reqBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		e.forget(baseURL)
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	return body, resp.StatusCode, err
+}
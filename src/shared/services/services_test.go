@@ -0,0 +1,40 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEndpointForgetsUnreachableInstances(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachable := server.URL
+	server.Close()
+
+	tests := []struct {
+		name        string
+		host        string
+		wantBaseURL string
+	}{
+		{name: "discovered instance is discovered again", host: "", wantBaseURL: ""},
+		{name: "configured host is kept", host: "127.0.0.1", wantBaseURL: unreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEndpoint("orders", tt.host, "80", nil)
+			e.baseURL = unreachable
+
+			if _, _, err := e.Do(&http.Client{Timeout: time.Second}, "GET", "/", nil, nil); err == nil {
+				t.Fatalf("Do() succeeded against a closed server")
+			}
+			if e.baseURL != tt.wantBaseURL {
+				t.Errorf("baseURL = %q, want %q", e.baseURL, tt.wantBaseURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package tax computes sales tax from a rules file shared by the services
+// that price carts and orders, so a cart and the order it becomes are taxed
+// the same way.
+package tax
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/address"
+	"shared/money"
+)
+
+// The rules file is checked for changes every rulesCheckInterval, so rates can
+// be changed without a restart
+const rulesCheckInterval = 5 * time.Second
+
+// Rule Struct - a rate charged on sales to an address. A rule matches an
+// address in its country, and in its state and zip code prefix when they are
+// set. Every matching rule is charged, so country, state and local rates add
+// up. Items in an exempt category are not taxed by the rule.
+type Rule struct {
+	Name             string   `json:"name"`
+	Country          string   `json:"country"`
+	State            string   `json:"state,omitempty"`
+	ZipPrefix        string   `json:"zip_prefix,omitempty"`
+	Rate             float64  `json:"rate"`
+	ExemptCategories []string `json:"exempt_categories,omitempty"`
+}
+
+// Rules Struct - the contents of the rules file
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Line Struct - the tax charged by one rule
+type Line struct {
+	Name          string  `json:"name" yaml:"name"`
+	Rate          float64 `json:"rate" yaml:"rate"`
+	TaxableAmount float32 `json:"taxable_amount" yaml:"taxable_amount"`
+	Amount        float32 `json:"amount" yaml:"amount"`
+}
+
+// Item Struct - the amount paid for a line and the product category
+// that decides whether it is exempt
+type Item struct {
+	Category string
+	Amount   float64
+}
+
+// Result Struct - the tax for a sale
+type Result struct {
+	Lines   []Line
+	ItemTax []float64 // tax on each item, unrounded
+	Total   float32
+}
+
+// matches reports whether the rule applies to an address
+func (r Rule) matches(country string, state string, zip string) bool {
+	if !strings.EqualFold(r.Country, country) {
+		return false
+	}
+	if len(r.State) > 0 && !strings.EqualFold(r.State, state) {
+		return false
+	}
+	return strings.HasPrefix(zip, r.ZipPrefix)
+}
+
+// exempt reports whether the rule doesn't tax category
+func (r Rule) exempt(category string) bool {
+	for _, c := range r.ExemptCategories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Calculator computes tax from the rules in a file, reloading them when the
+// file changes. A file that can't be read or parsed is logged and the rules
+// already loaded are kept; until a file has been loaded no tax is charged.
+type Calculator struct {
+	mu        sync.Mutex
+	path      string
+	rules     []Rule
+	modTime   time.Time
+	nextCheck time.Time
+}
+
+// NewCalculator Function
+func NewCalculator(path string) *Calculator {
+	return &Calculator{path: path}
+}
+
+// Calculate returns the tax on items sold to an address. The address is
+// normalized first, so it matches rules however its country, state and zip
+// code were written.
+func (c *Calculator) Calculate(to address.Address, items []Item) Result {
+	to = address.Normalize(to)
+	country, state, zip := to.Country, to.State, to.ZipCode
+
+	result := Result{Lines: []Line{}, ItemTax: make([]float64, len(items))}
+
+	var total float64
+	for _, rule := range c.currentRules() {
+		if !rule.matches(country, state, zip) {
+			continue
+		}
+
+		var taxable, amount float64
+		for i, item := range items {
+			if item.Amount <= 0 || rule.exempt(item.Category) {
+				continue
+			}
+			taxable += item.Amount
+			amount += item.Amount * rule.Rate
+			result.ItemTax[i] += item.Amount * rule.Rate
+		}
+		if taxable == 0 {
+			continue
+		}
+
+		line := Line{Name: rule.Name, Rate: rule.Rate, TaxableAmount: money.Round(taxable), Amount: money.Round(amount)}
+		result.Lines = append(result.Lines, line)
+		total += float64(line.Amount)
+	}
+
+	result.Total = money.Round(total)
+	return result
+}
+
+// currentRules returns the loaded rules, first reloading the file if it has
+// changed since it was last checked
+func (c *Calculator) currentRules() []Rule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(c.nextCheck) {
+		return c.rules
+	}
+	c.nextCheck = now.Add(rulesCheckInterval)
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		if c.modTime.IsZero() && c.rules == nil {
+			log.Println("Unable to read tax rules; no tax will be charged: ", err)
+			c.rules = []Rule{}
+		}
+		return c.rules
+	}
+	if info.ModTime().Equal(c.modTime) {
+		return c.rules
+	}
+
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		log.Println("Unable to read tax rules; keeping current rules: ", err)
+		return c.rules
+	}
+
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Println("Unable to parse tax rules; keeping current rules: ", err)
+		c.modTime = info.ModTime()
+		return c.rules
+	}
+
+	log.Println("Loaded tax rules from: ", c.path)
+	c.rules = rules.Rules
+	c.modTime = info.ModTime()
+	return c.rules
+}
@@ -0,0 +1,170 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package tax
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shared/address"
+)
+
+const testRules = `{"rules": [
+	{"name": "Washington state tax", "country": "US", "state": "WA", "rate": 0.065, "exempt_categories": ["groceries"]},
+	{"name": "Seattle tax", "country": "US", "state": "WA", "zip_prefix": "981", "rate": 0.0385, "exempt_categories": ["groceries"]},
+	{"name": "Ontario HST", "country": "CA", "state": "ON", "rate": 0.13}
+]}`
+
+// writeRules writes rules to a file in a new temporary directory and returns
+// its path. The caller removes the directory.
+func writeRules(t *testing.T, rules string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "tax")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "tax-rules.json")
+	if err := ioutil.WriteFile(path, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCalculate(t *testing.T) {
+	path := writeRules(t, testRules)
+	defer os.RemoveAll(filepath.Dir(path))
+	calculator := NewCalculator(path)
+
+	tests := []struct {
+		name      string
+		to        address.Address
+		items     []Item
+		wantTotal float32
+		wantLines []string
+		wantItem  []float64
+	}{
+		{
+			name:      "state and local rates add up",
+			to:        address.Address{Country: "US", State: "WA", ZipCode: "98101"},
+			items:     []Item{{Category: "apparel", Amount: 100}},
+			wantTotal: 10.35,
+			wantLines: []string{"Washington state tax", "Seattle tax"},
+			wantItem:  []float64{10.35},
+		},
+		{
+			name:      "outside the local zip prefix",
+			to:        address.Address{Country: "US", State: "WA", ZipCode: "99201"},
+			items:     []Item{{Category: "apparel", Amount: 100}},
+			wantTotal: 6.5,
+			wantLines: []string{"Washington state tax"},
+			wantItem:  []float64{6.5},
+		},
+		{
+			name:      "exempt category",
+			to:        address.Address{Country: "US", State: "WA", ZipCode: "98101"},
+			items:     []Item{{Category: "Groceries", Amount: 50}, {Category: "apparel", Amount: 10}},
+			wantTotal: 1.04,
+			wantLines: []string{"Washington state tax", "Seattle tax"},
+			wantItem:  []float64{0, 1.035},
+		},
+		{
+			name:      "address is normalized before matching",
+			to:        address.Address{Country: "United States", State: "washington", ZipCode: "981011234"},
+			items:     []Item{{Category: "apparel", Amount: 100}},
+			wantTotal: 10.35,
+			wantLines: []string{"Washington state tax", "Seattle tax"},
+			wantItem:  []float64{10.35},
+		},
+		{
+			name:      "canadian province by name",
+			to:        address.Address{Country: "Canada", State: "Ontario", ZipCode: "k1a0b1"},
+			items:     []Item{{Category: "apparel", Amount: 20}},
+			wantTotal: 2.6,
+			wantLines: []string{"Ontario HST"},
+			wantItem:  []float64{2.6},
+		},
+		{
+			name:      "no matching rules",
+			to:        address.Address{Country: "US", State: "OR", ZipCode: "97201"},
+			items:     []Item{{Category: "apparel", Amount: 100}},
+			wantTotal: 0,
+			wantLines: []string{},
+			wantItem:  []float64{0},
+		},
+		{
+			name:      "fully discounted items aren't taxed",
+			to:        address.Address{Country: "US", State: "WA", ZipCode: "98101"},
+			items:     []Item{{Category: "apparel", Amount: 0}},
+			wantTotal: 0,
+			wantLines: []string{},
+			wantItem:  []float64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculator.Calculate(tt.to, tt.items)
+			if result.Total != tt.wantTotal {
+				t.Errorf("Total = %v, want %v", result.Total, tt.wantTotal)
+			}
+
+			names := []string{}
+			for _, line := range result.Lines {
+				names = append(names, line.Name)
+			}
+			if len(names) != len(tt.wantLines) {
+				t.Fatalf("Lines = %v, want %v", names, tt.wantLines)
+			}
+			for i := range names {
+				if names[i] != tt.wantLines[i] {
+					t.Errorf("Lines = %v, want %v", names, tt.wantLines)
+				}
+			}
+
+			for i, want := range tt.wantItem {
+				if math.Abs(result.ItemTax[i]-want) > 1e-9 {
+					t.Errorf("ItemTax[%d] = %v, want %v", i, result.ItemTax[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateWithoutRules(t *testing.T) {
+	calculator := NewCalculator(filepath.Join(os.TempDir(), "missing-tax-rules.json"))
+	result := calculator.Calculate(address.Address{Country: "US", State: "WA", ZipCode: "98101"}, []Item{{Amount: 100}})
+	if result.Total != 0 || len(result.Lines) != 0 {
+		t.Errorf("Calculate() = %+v, want no tax", result)
+	}
+}
+
+func TestCalculatorKeepsRulesWhenFileIsInvalid(t *testing.T) {
+	path := writeRules(t, testRules)
+	defer os.RemoveAll(filepath.Dir(path))
+	calculator := NewCalculator(path)
+	to := address.Address{Country: "US", State: "WA", ZipCode: "99201"}
+	items := []Item{{Category: "apparel", Amount: 100}}
+
+	if got := calculator.Calculate(to, items).Total; got != 6.5 {
+		t.Fatalf("Total = %v, want 6.5", got)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	calculator.nextCheck = time.Time{}
+
+	if got := calculator.Calculate(to, items).Total; got != 6.5 {
+		t.Errorf("Total after invalid file = %v, want 6.5", got)
+	}
+}
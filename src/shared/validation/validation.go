@@ -0,0 +1,49 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+// Package validation reports problems with the fields of a request in the
+// same shape from every service.
+package validation
+
+import "strings"
+
+// FieldError Struct - a problem with one field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is returned when a request has one or more invalid fields. Every
+// problem found is reported, not just the first.
+type Error struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		messages[i] = fe.Field + ": " + fe.Message
+	}
+	return "Invalid request: " + strings.Join(messages, "; ")
+}
+
+// Add records a problem with field
+func (e *Error) Add(field string, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+}
+
+// AddAll records problems found in a nested value, naming their fields under
+// prefix
+func (e *Error) AddAll(prefix string, problems []FieldError) {
+	for _, problem := range problems {
+		e.Add(prefix+"."+problem.Field, problem.Message)
+	}
+}
+
+// OrNil returns e if it holds any errors, so callers can return it as an error
+func (e *Error) OrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
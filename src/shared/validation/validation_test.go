@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package validation
+
+import "testing"
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(e *Error)
+		wantNil  bool
+		wantText string
+	}{
+		{
+			name:    "no problems",
+			build:   func(e *Error) {},
+			wantNil: true,
+		},
+		{
+			name:     "one problem",
+			build:    func(e *Error) { e.Add("items", "must contain at least one item") },
+			wantText: "Invalid request: items: must contain at least one item",
+		},
+		{
+			name: "nested problems",
+			build: func(e *Error) {
+				e.AddAll("shipping_address", []FieldError{{Field: "city", Message: "is required"}, {Field: "zipcode", Message: "is required"}})
+			},
+			wantText: "Invalid request: shipping_address.city: is required; shipping_address.zipcode: is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Error{}
+			tt.build(e)
+			err := e.OrNil()
+			if tt.wantNil {
+				if err != nil {
+					t.Fatalf("OrNil() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("OrNil() = nil, want an error")
+			}
+			if err.Error() != tt.wantText {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantText)
+			}
+		})
+	}
+}
@@ -6,4 +6,7 @@ require (
 	github.com/aws/aws-sdk-go v1.44.97
 	github.com/gorilla/mux v1.8.0
 	gopkg.in/yaml.v2 v2.4.0 // indirect
+	shared v0.0.0
 )
+
+replace shared => ../../../shared
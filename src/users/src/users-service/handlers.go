@@ -222,6 +222,15 @@ func UserUpdate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := user.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(err); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	t := RepoUpdateUser(user)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusCreated)
@@ -254,6 +263,15 @@ func UserCreate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := user.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(err); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	t, err := RepoCreateUser(user)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,40 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: MIT-0
+
+package main
+
+import (
+	"strconv"
+
+	"shared/address"
+	"shared/validation"
+)
+
+// ValidationError is returned when a request has one or more invalid fields
+type ValidationError = validation.Error
+
+// Validate normalizes the user's addresses and checks each of them is
+// complete for its country. Exactly one address is left marked as the
+// default: the first one marked, or the first address when none is.
+func (u *User) Validate() error {
+	verr := &ValidationError{}
+
+	for i := range u.Addresses {
+		field := "addresses[" + strconv.Itoa(i) + "]"
+		u.Addresses[i] = address.Normalize(u.Addresses[i])
+		verr.AddAll(field, address.Validate(u.Addresses[i]))
+	}
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	if def, ok := address.Default(u.Addresses); ok {
+		marked := false
+		for i := range u.Addresses {
+			isDefault := !marked && u.Addresses[i] == def
+			u.Addresses[i].Default = isDefault
+			marked = marked || isDefault
+		}
+	}
+	return nil
+}